@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const defaultDeletionGraceDays = 14
+
+// deletionGracePeriod is read from ACCOUNT_DELETION_GRACE_DAYS.
+func deletionGracePeriod() time.Duration {
+	days := defaultDeletionGraceDays
+	if v, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS")); err == nil && v >= 0 {
+		days = v
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// RequestAccountDeletion schedules the caller's account for deletion after the
+// grace period. The deletion itself is done by workers.RunAccountDeletions.
+func RequestAccountDeletion(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		now := time.Now()
+		deletion := models.AccountDeletion{
+			ID:           bson.NewObjectID(),
+			UserID:       userObjId,
+			EmailHash:    utils.HashToken(strings.ToLower(user.Email)),
+			Status:       models.DeletionPending,
+			Active:       true,
+			RequestedAt:  now,
+			ScheduledFor: now.Add(deletionGracePeriod()),
+			Steps:        []models.DeletionStep{},
+		}
+
+		_, err := database.OpenCollection("account_deletions", client).InsertOne(ctx, deletion)
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Account deletion already scheduled"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule deletion"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":       "Account scheduled for deletion. You can cancel until then.",
+			"deletion":      deletion,
+			"scheduled_for": deletion.ScheduledFor,
+		})
+	}
+}
+
+func GetAccountDeletion(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var deletion models.AccountDeletion
+		err := database.OpenCollection("account_deletions", client).FindOne(
+			ctx,
+			bson.M{"user_id": userObjId, "active": true},
+		).Decode(&deletion)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No deletion scheduled"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deletion"})
+			return
+		}
+
+		c.JSON(http.StatusOK, deletion)
+	}
+}
+
+func CancelAccountDeletion(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		// Only a deletion that has not started yet can be cancelled.
+		result, err := database.OpenCollection("account_deletions", client).UpdateOne(
+			ctx,
+			bson.M{"user_id": userObjId, "active": true, "status": models.DeletionPending},
+			bson.M{
+				"$set":   bson.M{"status": models.DeletionCancelled, "cancelled_at": time.Now()},
+				"$unset": bson.M{"active": ""},
+			},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel deletion"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No cancellable deletion scheduled"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+	}
+}
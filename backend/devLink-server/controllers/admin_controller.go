@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// EnsureBootstrapAdmins promotes the verified accounts listed in the
+// comma-separated ADMIN_EMAILS variable to admin, so that a fresh instance has
+// someone who can manage roles.
+func EnsureBootstrapAdmins(client *mongo.Client) {
+	emails := []string{}
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	if len(emails) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := database.OpenCollection("users", client).UpdateMany(
+		ctx,
+		bson.M{"email": bson.M{"$in": emails}, "is_verified": true, "role": bson.M{"$ne": utils.RoleAdmin}},
+		bson.M{"$set": bson.M{"role": utils.RoleAdmin, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		log.Println("warning: failed to bootstrap admins:", err)
+		return
+	}
+	if result.ModifiedCount > 0 {
+		log.Println("Promoted bootstrap admins:", result.ModifiedCount)
+	}
+}
+
+func ListUsers(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := bson.M{}
+		if role := c.Query("role"); role != "" {
+			filter["role"] = role
+		}
+		switch c.Query("status") {
+		case "banned":
+			filter["ban"] = bson.M{"$exists": true}
+		case "suspended":
+			filter["suspension"] = bson.M{"$exists": true}
+		}
+
+		page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+		if page < 1 {
+			page = 1
+		}
+		const pageSize = 50
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := database.OpenCollection("users", client).Find(
+			ctx,
+			filter,
+			options.Find().
+				SetSort(bson.D{{Key: "created_at", Value: -1}}).
+				SetSkip((page-1)*pageSize).
+				SetLimit(pageSize),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		users := []gin.H{}
+		for cursor.Next(ctx) {
+			var user models.User
+			if err := cursor.Decode(&user); err != nil {
+				continue
+			}
+			users = append(users, gin.H{
+				"id":          user.Id.Hex(),
+				"name":        user.UserName,
+				"email":       user.Email,
+				"role":        user.Role,
+				"is_verified": user.IsVerified,
+				"suspension":  user.Suspension,
+				"ban":         user.Ban,
+				"created_at":  user.CreatedAt,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"users": users, "page": page})
+	}
+}
+
+// UpdateUserRole promotes or demotes a user. The user's outstanding access
+// tokens are revoked so the next refresh picks up the new role claim.
+func UpdateUserRole(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		targetId, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+			return
+		}
+
+		var req struct {
+			Role string `json:"role"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || !utils.IsValidRole(req.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var target models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": targetId}).Decode(&target); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if target.Role == req.Role {
+			c.JSON(http.StatusOK, gin.H{"message": "Role unchanged", "role": target.Role})
+			return
+		}
+
+		if target.Role == utils.RoleAdmin {
+			admins, err := userCollection.CountDocuments(ctx, bson.M{"role": utils.RoleAdmin})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+				return
+			}
+			if admins <= 1 {
+				c.JSON(http.StatusConflict, gin.H{"error": "Cannot demote the last admin"})
+				return
+			}
+		}
+
+		_, err = userCollection.UpdateOne(ctx, bson.M{"_id": targetId}, bson.M{
+			"$set": bson.M{"role": req.Role, "updated_at": time.Now()},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+			return
+		}
+
+		if err := utils.RevokeUserTokens(ctx, client, target.Id.Hex(), "role_change"); err != nil {
+			log.Println("ROLE CHANGE: failed to revoke tokens:", err)
+		}
+
+		actorId, _ := bson.ObjectIDFromHex(c.GetString("user_id"))
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditRoleChanged,
+			UserID:   auditUser(target.Id),
+			ActorID:  auditUser(actorId),
+			Email:    target.Email,
+			Metadata: bson.M{"previous_role": target.Role, "role": req.Role},
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":       "Role updated",
+			"user_id":       target.Id.Hex(),
+			"previous_role": target.Role,
+			"role":          req.Role,
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// recordAudit stamps event with the client's IP, user agent and session and
+// appends it to the audit log. A failure is logged but never fails the
+// request that caused the event.
+func recordAudit(c *gin.Context, client *mongo.Client, event models.AuditEvent) {
+	event.IP = c.ClientIP()
+	event.UserAgent = c.Request.UserAgent()
+	if event.SessionID == "" {
+		event.SessionID = c.GetString("session_id")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := utils.RecordAuditEvent(ctx, client, event); err != nil {
+		log.Println("AUDIT: failed to record", event.Type, "event:", err)
+	}
+}
+
+// recordLogin records a successful sign-in of user; method says which flow
+// completed it, e.g. "password" or "magic_link".
+func recordLogin(c *gin.Context, client *mongo.Client, user models.User, method string) {
+	recordAudit(c, client, models.AuditEvent{
+		Type:     utils.AuditLoginSucceeded,
+		UserID:   auditUser(user.Id),
+		Email:    user.Email,
+		Metadata: bson.M{"method": method},
+	})
+}
+
+// auditUser returns a pointer to a copy of id, for AuditEvent.UserID and
+// AuditEvent.ActorID.
+func auditUser(id bson.ObjectID) *bson.ObjectID {
+	return &id
+}
+
+// ListMyAuditEvents returns the caller's own security history, newest first,
+// optionally filtered by ?type=.
+func ListMyAuditEvents(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+		filter := bson.M{"user_id": userObjId}
+
+		if eventType := c.Query("type"); eventType != "" {
+			if !utils.IsAuditEventType(eventType) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type"})
+				return
+			}
+			filter["type"] = eventType
+		}
+
+		listAuditEvents(c, client, filter)
+	}
+}
+
+// ListAuditEvents lets admins query the audit log by ?user_id=, ?type= and
+// ?email=, newest first.
+func ListAuditEvents(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := bson.M{}
+
+		if userId := c.Query("user_id"); userId != "" {
+			userObjId, err := bson.ObjectIDFromHex(userId)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+				return
+			}
+			filter["user_id"] = userObjId
+		}
+		if eventType := c.Query("type"); eventType != "" {
+			if !utils.IsAuditEventType(eventType) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type"})
+				return
+			}
+			filter["type"] = eventType
+		}
+		if email := c.Query("email"); email != "" {
+			filter["email"] = email
+		}
+
+		listAuditEvents(c, client, filter)
+	}
+}
+
+func listAuditEvents(c *gin.Context, client *mongo.Client, filter bson.M) {
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	const pageSize = 50
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := database.OpenCollection("audit_events", client).Find(
+		ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip((page-1)*pageSize).
+			SetLimit(pageSize),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+		return
+	}
+
+	events := []models.AuditEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events, "page": page})
+}
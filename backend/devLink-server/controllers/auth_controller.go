@@ -3,10 +3,14 @@ package controllers
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"math/big"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ayushmehta03/devLink-backend/utils"
@@ -17,6 +21,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -44,10 +49,91 @@ func HashPassword(password string) (string,error){
 }
 
 
+func respondTooManyAttempts(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed attempts. Please try again later.",
+		"code":        "TOO_MANY_ATTEMPTS",
+		"retry_after": seconds,
+	})
+}
 
+// recordOTPFailure counts a wrong guess against the OTP stored under the
+// <prefix>_hash field and invalidates the OTP once utils.MaxOTPAttempts is
+// reached. It reports whether the OTP was invalidated.
+func recordOTPFailure(ctx context.Context, userCollection *mongo.Collection, userID bson.ObjectID, prefix, otpHash string) (bool, error) {
+	hashField := prefix + "_hash"
+	attemptsField := prefix + "_attempts"
+
+	var updated bson.M
+	err := userCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": userID, hashField: otpHash},
+		bson.M{"$inc": bson.M{attemptsField: 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Already replaced or invalidated by a concurrent request.
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
 
+	var attempts int64
+	switch v := updated[attemptsField].(type) {
+	case int32:
+		attempts = int64(v)
+	case int64:
+		attempts = v
+	}
+	if attempts < utils.MaxOTPAttempts {
+		return false, nil
+	}
 
-func RegisterUser(client *mongo.Client) gin.HandlerFunc {
+	_, err = userCollection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$unset": bson.M{
+			hashField:          "",
+			prefix + "_expiry": "",
+			attemptsField:      "",
+		},
+	})
+	return true, err
+}
+
+
+
+
+
+func generatedAvatarURL(name string) string {
+	return utils.InitialsAvatarURL(name)
+}
+
+// isGeneratedAvatar reports whether profileImage is still the initials avatar
+// assigned at sign-up rather than one the user picked.
+func isGeneratedAvatar(profileImage string) bool {
+	return profileImage == "" || utils.IsInitialsAvatar(profileImage)
+}
+
+
+// otpEmailKey is the idempotency key of the email carrying the OTP whose hash
+// is otpHash, so each generated code is mailed at most once.
+func otpEmailKey(email, otpHash string) string {
+	return "otp:" + utils.HashToken(strings.ToLower(email)+":"+otpHash)
+}
+
+// registrationMetadata describes how a password registration was let in.
+func registrationMetadata(invite *models.Invite) bson.M {
+	metadata := bson.M{"method": "password"}
+	if invite != nil {
+		metadata["invite_id"] = invite.ID.Hex()
+		metadata["invited_by"] = invite.CreatedBy.Hex()
+	}
+	return metadata
+}
+
+func RegisterUser(client *mongo.Client, mailer utils.Mailer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var user models.User
@@ -66,6 +152,15 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
+		inviteRequired := utils.InviteRequired(user.Email)
+		if inviteRequired && strings.TrimSpace(user.InviteCode) == "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "An invite code is required to register",
+				"code":  "INVITE_REQUIRED",
+			})
+			return
+		}
+
 		hashedPassword, err := HashPassword(user.Password)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
@@ -89,13 +184,61 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 		}
 
 
-		    avatarURL := fmt.Sprintf(
-        "https://api.dicebear.com/7.x/initials/svg?seed=%s",
-    user.UserName,
-    )
-
+		    avatarURL := generatedAvatarURL(user.UserName)
+
+		user.Handle = strings.TrimPrefix(strings.TrimSpace(user.Handle), "@")
+		if user.Handle != "" {
+			if err := utils.ValidateHandle(user.Handle); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "HANDLE_INVALID"})
+				return
+			}
+			available, err := utils.IsHandleAvailable(ctx, client, user.Handle, bson.ObjectID{})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check handle"})
+				return
+			}
+			if !available {
+				c.JSON(http.StatusConflict, gin.H{"error": "Handle is already taken", "code": "HANDLE_TAKEN"})
+				return
+			}
+		} else {
+			handle, err := utils.SuggestHandle(ctx, client, user.UserName, bson.ObjectID{})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign handle"})
+				return
+			}
+			user.Handle = handle
+		}
+		user.HandleLower = utils.NormalizeHandle(user.Handle)
+		user.EmailLower = utils.NormalizeEmail(user.Email)
 
 		
+		user.Id = bson.NewObjectID()
+
+		// Redeeming takes a slot of the invite; every failure from here on
+		// has to give it back.
+		var invite *models.Invite
+		if inviteRequired {
+			invite, err = utils.RedeemInvite(ctx, client, user.InviteCode, user.Id, user.Email)
+			if errors.Is(err, utils.ErrInvalidInvite) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "INVITE_INVALID"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check invite"})
+				return
+			}
+			user.InviteID = &invite.ID
+		}
+		releaseInvite := func() {
+			if invite == nil {
+				return
+			}
+			if err := utils.ReleaseInvite(ctx, client, invite.ID, user.Id); err != nil {
+				log.Println("REGISTER: failed to release invite:", err)
+			}
+		}
+
 		otp := GenerateOTP()
 		otpHash, _ := HashPassword(otp)
 
@@ -104,25 +247,49 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 		user.UpdatedAt = time.Now()
 		user.Password = hashedPassword
 		user.IsVerified = false
-		user.Role = "user"
+		user.Role = utils.RoleUser
+		user.FollowersCount = 0
+		user.FollowingCount = 0
+		user.Locale = utils.MatchLocale(c.GetHeader("Accept-Language"))
 		user.OTPHash = otpHash
 		user.ProfileImage=avatarURL
 		user.OTPExpiry = time.Now().Add(10 * time.Minute)
 
-		if _, err := userCollection.InsertOne(ctx, user); err != nil {
+		result, err := userCollection.InsertOne(ctx, user)
+		if err != nil {
+			releaseInvite()
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Handle is already taken", "code": "HANDLE_TAKEN"})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 			return
 		}
-	
-		if err := utils.SendOTPEmail(user.Email, otp); err != nil {
+
+		// The OTP email is only queued here; delivery is retried in the
+		// background. If it cannot even be queued, undo the registration so
+		// the email address is not left half-registered.
+		if err := utils.SendOTPEmail(ctx, mailer, otpEmailKey(user.Email, otpHash), user.Email, user.Locale, otp); err != nil {
 			log.Println("OTP EMAIL FAILED:", err)
 
+			userCollection.DeleteOne(ctx, bson.M{"_id": result.InsertedID})
+			releaseInvite()
+
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to send OTP email. Please try again.",
 			})
 			return
 		}
 
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditRegister,
+			UserID:   auditUser(result.InsertedID.(bson.ObjectID)),
+			Email:    user.Email,
+			Metadata: registrationMetadata(invite),
+		})
+
 		c.JSON(http.StatusCreated, gin.H{
 			"message": "User registered. OTP sent to email.",
 		})
@@ -145,11 +312,24 @@ func VerifyOtp(client *mongo.Client) gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		accountKey := "otp:acct:" + strings.ToLower(req.Email)
+		ipKey := "otp:ip:" + c.ClientIP()
+
+		if wait, err := utils.CheckLockout(ctx, client, accountKey, ipKey); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Verification failed"})
+			return
+		} else if wait > 0 {
+			respondTooManyAttempts(c, wait)
+			return
+		}
+
 		userCollection := database.OpenCollection("users", client)
 
 		var user models.User
 		err := userCollection.FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
 		if err != nil {
+			utils.RecordFailure(ctx, client, ipKey, utils.OTPIPPolicy)
+			recordAudit(c, client, models.AuditEvent{Type: utils.AuditOTPFailed, Email: req.Email, Reason: "unknown_email"})
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 			return
 		}
@@ -159,7 +339,7 @@ func VerifyOtp(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		if time.Now().After(user.OTPExpiry) {
+		if user.OTPHash == "" || time.Now().After(user.OTPExpiry) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "OTP expired"})
 			return
 		}
@@ -169,6 +349,28 @@ func VerifyOtp(client *mongo.Client) gin.HandlerFunc {
 			[]byte(req.OTP),
 		)
 		if err != nil {
+			recordOTPGuessFailure(ctx, c, client, accountKey, ipKey)
+
+			invalidated, err := recordOTPFailure(ctx, userCollection, user.Id, "otp", user.OTPHash)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Verification failed"})
+				return
+			}
+
+			event := models.AuditEvent{Type: utils.AuditOTPFailed, UserID: auditUser(user.Id), Email: user.Email, Reason: "invalid_otp"}
+			if invalidated {
+				event.Reason = "otp_invalidated"
+			}
+			recordAudit(c, client, event)
+
+			if invalidated {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Too many invalid attempts. Please request a new OTP.",
+					"code":  "OTP_INVALIDATED",
+				})
+				return
+			}
+
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP"})
 			return
 		}
@@ -179,8 +381,9 @@ func VerifyOtp(client *mongo.Client) gin.HandlerFunc {
 				"updated_at":  time.Now(),
 			},
 			"$unset": bson.M{
-				"otp_hash":   "",
-				"otp_expiry": "",
+				"otp_hash":     "",
+				"otp_expiry":   "",
+				"otp_attempts": "",
 			},
 		}
 
@@ -190,21 +393,20 @@ func VerifyOtp(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		token, err := utils.GenerateToken(user.Id.Hex(),user.Email,user.Role)
-		if err != nil {
+		utils.ResetAttempts(ctx, client, accountKey)
+
+		recordAudit(c, client, models.AuditEvent{Type: utils.AuditOTPVerified, UserID: auditUser(user.Id), Email: user.Email})
+
+		if rejectRestricted(c, client, user) {
+			return
+		}
+
+		if err := startSession(ctx, c, client, user, false); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 			return
 		}
 
-		c.SetCookie(
-			"access_token",
-			token,
-			3600*24, 
-			"/",
-			"localhost",
-			true,  
-			true,  
-		)
+		recordLogin(c, client, user, "otp")
 
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Account verified and logged in",
@@ -212,7 +414,7 @@ func VerifyOtp(client *mongo.Client) gin.HandlerFunc {
 	}
 }
 
-func ResendOtp(client *mongo.Client) gin.HandlerFunc {
+func ResendOtp(client *mongo.Client, mailer utils.Mailer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var req struct {
@@ -227,6 +429,10 @@ func ResendOtp(client *mongo.Client) gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		if throttleOTPSend(ctx, c, client, "resend", req.Email) {
+			return
+		}
+
 		userCollection := database.OpenCollection("users", client)
 
 		var user models.User
@@ -254,6 +460,9 @@ func ResendOtp(client *mongo.Client) gin.HandlerFunc {
 				"otp_expiry": time.Now().Add(10 * time.Minute),
 				"updated_at": time.Now(),
 			},
+			"$unset": bson.M{
+				"otp_attempts": "",
+			},
 		}
 
 		if _, err := userCollection.UpdateOne(ctx, bson.M{"email": req.Email}, update); err != nil {
@@ -261,7 +470,7 @@ func ResendOtp(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		if err := utils.SendOTPEmail(user.Email, newOtp); err != nil {
+		if err := utils.SendOTPEmail(ctx, mailer, otpEmailKey(user.Email, hashedOtp), user.Email, user.Locale, newOtp); err != nil {
 			log.Println("RESEND OTP EMAIL FAILED:", err)
 
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -294,6 +503,18 @@ func LoginUser(client *mongo.Client) gin.HandlerFunc{
 
 		defer cancel()
 
+		accountKey:="login:account:"+strings.ToLower(loginReq.Email)
+		ipKey:="login:ip:"+c.ClientIP()
+
+		if wait,err:=utils.CheckLockout(ctx,client,accountKey,ipKey);err!=nil{
+			c.JSON(http.StatusInternalServerError,gin.H{"error":"Login failed"})
+			return
+		}else if wait>0{
+			recordAudit(c,client,models.AuditEvent{Type:utils.AuditLoginFailed,Email:loginReq.Email,Reason:"locked_out"})
+			respondTooManyAttempts(c,wait)
+			return
+		}
+
 		userCollection:=database.OpenCollection("users",client)
 
 
@@ -302,11 +523,14 @@ func LoginUser(client *mongo.Client) gin.HandlerFunc{
 		err:=userCollection.FindOne(ctx,bson.M{"email":loginReq.Email}).Decode(&user)
 
 		if err != nil {
+			recordLoginFailure(ctx,c,client,accountKey,ipKey)
+			recordAudit(c,client,models.AuditEvent{Type:utils.AuditLoginFailed,Email:loginReq.Email,Reason:"unknown_email"})
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "No account found with this email "})
 			return
 		}
 
 		if !user.IsVerified {
+	recordAudit(c,client,models.AuditEvent{Type:utils.AuditLoginFailed,UserID:auditUser(user.Id),Email:user.Email,Reason:"not_verified"})
 	c.JSON(http.StatusForbidden, gin.H{
 		"error": "Account not verified",
 		"code":  "ACCOUNT_NOT_VERIFIED",
@@ -323,30 +547,43 @@ func LoginUser(client *mongo.Client) gin.HandlerFunc{
 		)
 
 		if err!=nil{
+			recordLoginFailure(ctx,c,client,accountKey,ipKey)
+			recordAudit(c,client,models.AuditEvent{Type:utils.AuditLoginFailed,UserID:auditUser(user.Id),Email:user.Email,Reason:"invalid_password"})
 			c.JSON(http.StatusUnauthorized,gin.H{"error":"Invalid email or password"})
 			return 
 		}
 
-		token,err:=utils.GenerateToken(
-			user.Id.Hex(),
-			user.Email,
-			user.Role,
-		)
+		// Only the account counter is cleared: a valid login of one account
+		// must not wipe the IP's record of guessing at others.
+		utils.ResetAttempts(ctx,client,accountKey)
 
-		if err!=nil{
+		if rejectRestricted(c,client,user){
+			return
+		}
+
+		if user.TOTPEnabled{
+			mfaToken,err:=utils.GeneratePurposeToken(user.Id.Hex(),"mfa",mfaChallengeTTL)
+			if err!=nil{
+				c.JSON(http.StatusInternalServerError,gin.H{"error":"Failed to generate token "})
+				return
+			}
+
+			c.JSON(http.StatusOK,gin.H{
+				"message":"Two-factor authentication required",
+				"code":"MFA_REQUIRED",
+				"mfa_required":true,
+				"mfa_token":mfaToken,
+				"expires_in":int(mfaChallengeTTL.Seconds()),
+			})
+			return
+		}
+
+		if err:=startSession(ctx,c,client,user,true);err!=nil{
 			c.JSON(http.StatusInternalServerError,gin.H{"error":"Failed to generate token "})
 			return 
 		}
 
-	  c.SetCookie(
-	"access_token",
-	token,
-	3600*24,
-	"/",
-	"localhost",
-	false,   
-	true,   
-)
+		recordLogin(c,client,user,"password")
 
 
 
@@ -366,18 +603,103 @@ func LoginUser(client *mongo.Client) gin.HandlerFunc{
 	}
 }
 
-func LogoutUser() gin.HandlerFunc {
+// recordLoginFailure counts a failed login against the account and the client
+// IP, and announces any lockout it triggered through Retry-After.
+func recordLoginFailure(ctx context.Context, c *gin.Context, client *mongo.Client, accountKey, ipKey string) {
+	var wait time.Duration
+
+	if d, err := utils.RecordFailure(ctx, client, accountKey, utils.LoginAccountPolicy); err != nil {
+		log.Println("LOGIN: failed to record attempt:", err)
+	} else if d > wait {
+		wait = d
+	}
+
+	if d, err := utils.RecordFailure(ctx, client, ipKey, utils.LoginIPPolicy); err != nil {
+		log.Println("LOGIN: failed to record attempt:", err)
+	} else if d > wait {
+		wait = d
+	}
+
+	if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	}
+}
+
+// recordOTPGuessFailure counts a wrong verification or reset code against the
+// account and the client IP. The account counter is kept apart from the
+// per-code attempts, which start over whenever a new code is sent.
+func recordOTPGuessFailure(ctx context.Context, c *gin.Context, client *mongo.Client, accountKey, ipKey string) {
+	var wait time.Duration
+
+	if d, err := utils.RecordFailure(ctx, client, accountKey, utils.OTPAccountPolicy); err != nil {
+		log.Println("OTP: failed to record attempt:", err)
+	} else if d > wait {
+		wait = d
+	}
+
+	if d, err := utils.RecordFailure(ctx, client, ipKey, utils.OTPIPPolicy); err != nil {
+		log.Println("OTP: failed to record attempt:", err)
+	} else if d > wait {
+		wait = d
+	}
+
+	if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	}
+}
+
+// throttleOTPSend counts a request to email a code of the given kind to email
+// and responds with 429 once the address or the client IP asks too often. It
+// reports whether the request was rejected.
+func throttleOTPSend(ctx context.Context, c *gin.Context, client *mongo.Client, kind, email string) bool {
+	accountKey := kind + ":account:" + strings.ToLower(email)
+	ipKey := kind + ":ip:" + c.ClientIP()
+
+	wait, err := utils.CheckLockout(ctx, client, accountKey, ipKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send code"})
+		return true
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return true
+	}
+
+	utils.RecordFailure(ctx, client, accountKey, utils.OTPSendPolicy)
+	utils.RecordFailure(ctx, client, ipKey, utils.LoginIPPolicy)
+	return false
+}
+
+func LogoutUser(client *mongo.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		c.SetCookie(
-			"access_token",
-			"",
-			-1,
-			"/",
-			"localhost",
-			false,
-			true,
-		)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if tokenStr, err := c.Cookie("access_token"); err == nil && tokenStr != "" {
+			if claims, err := utils.VerifyToken(tokenStr); err == nil {
+				if err := utils.RevokeToken(ctx, client, claims, "logout"); err != nil {
+					log.Println("LOGOUT: failed to revoke access token:", err)
+				}
+			}
+		}
+
+		if refreshToken, err := c.Cookie("refresh_token"); err == nil && refreshToken != "" {
+			if session, err := utils.FindSessionByRefreshToken(ctx, client, refreshToken); err == nil {
+				if err := utils.RevokeSession(ctx, client, session.ID, "logout"); err != nil {
+					log.Println("LOGOUT: failed to revoke session:", err)
+				}
+				disconnectSession(session.ID.Hex())
+
+				recordAudit(c, client, models.AuditEvent{
+					Type:      utils.AuditLogout,
+					UserID:    auditUser(session.UserID),
+					SessionID: session.ID.Hex(),
+				})
+			}
+		}
+
+		clearAuthCookies(c)
 
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Logged out successfully",
@@ -398,7 +720,7 @@ func GetMe(client *mongo.Client) gin.HandlerFunc {
 
 		// 2️⃣ Verify token
 		claims, err := utils.VerifyToken(tokenStr)
-		if err != nil {
+		if err != nil || claims.SessionID == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid token",
 			})
@@ -417,6 +739,13 @@ func GetMe(client *mongo.Client) gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		if revoked, err := utils.IsTokenRevoked(ctx, client, claims); err != nil || revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid token",
+			})
+			return
+		}
+
 		userCollection := database.OpenCollection("users", client)
 
 		var user models.User
@@ -435,15 +764,280 @@ func GetMe(client *mongo.Client) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{
 			"id":            user.Id,
 			"username":      user.UserName,
+			"handle":        user.Handle,
 			"email":         user.Email,
 			"profile_image": user.ProfileImage,
 			"role":          user.Role,
+			"totp_enabled":  user.TOTPEnabled,
 			"created_at":    user.CreatedAt,
 		})
 	}
 }
 
+func ChangePassword(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req struct {
+			CurrentPassword string `json:"current_password" validate:"required"`
+			NewPassword     string `json:"new_password" validate:"required,min=6"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+
+		hashedPassword, err := HashPassword(req.NewPassword)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		_, err = userCollection.UpdateOne(ctx, bson.M{"_id": userObjId}, bson.M{
+			"$set": bson.M{
+				"password":   hashedPassword,
+				"updated_at": time.Now(),
+			},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+			return
+		}
+
+		// Sign out everywhere, then give this browser a fresh session.
+		if err := utils.RevokeUserSessions(ctx, client, user.Id, "password_change"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+		if err := utils.RevokeUserTokens(ctx, client, user.Id.Hex(), "password_change"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke tokens"})
+			return
+		}
+		disconnectUser(user.Id.Hex())
+
+		recordAudit(c, client, models.AuditEvent{Type: utils.AuditPasswordChanged, UserID: auditUser(user.Id), Email: user.Email})
+
+		if err := startSession(ctx, c, client, user, true); err != nil {
+			clearAuthCookies(c)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed, please log in again"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+	}
+}
+
+// ForgotPassword emails a password reset OTP. It answers the same way whether
+// or not the email is registered so it cannot be used to probe for accounts.
+func ForgotPassword(client *mongo.Client, mailer utils.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+			return
+		}
+
+		response := gin.H{
+			"message": "If an account exists for this email, a reset code has been sent.",
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if throttleOTPSend(ctx, c, client, "forgot", req.Email) {
+			return
+		}
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"email": req.Email}).Decode(&user); err != nil {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		// Hashing, storing and sending all happen in the background: the
+		// bcrypt hash alone takes long enough to tell registered emails
+		// apart from unknown ones if the response waited for it.
+		go func(user models.User) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			otp := GenerateOTP()
+			otpHash, err := HashPassword(otp)
+			if err != nil {
+				log.Println("FORGOT PASSWORD: failed to hash OTP:", err)
+				return
+			}
+
+			_, err = database.OpenCollection("users", client).UpdateOne(ctx, bson.M{"_id": user.Id}, bson.M{
+				"$set": bson.M{
+					"reset_otp_hash":   otpHash,
+					"reset_otp_expiry": time.Now().Add(10 * time.Minute),
+				},
+				"$unset": bson.M{
+					"reset_otp_attempts": "",
+				},
+			})
+			if err != nil {
+				log.Println("FORGOT PASSWORD: failed to store OTP:", err)
+				return
+			}
+
+			if err := utils.SendPasswordResetEmail(ctx, mailer, "reset:"+utils.HashToken(otpHash), user.Email, user.Locale, otp); err != nil {
+				log.Println("RESET OTP EMAIL FAILED:", err)
+			}
+		}(user)
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func ResetPassword(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req struct {
+			Email       string `json:"email" validate:"required,email"`
+			OTP         string `json:"otp" validate:"required"`
+			NewPassword string `json:"new_password" validate:"required,min=6"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		accountKey := "reset:acct:" + strings.ToLower(req.Email)
+		ipKey := "reset:ip:" + c.ClientIP()
+
+		if wait, err := utils.CheckLockout(ctx, client, accountKey, ipKey); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+			return
+		} else if wait > 0 {
+			respondTooManyAttempts(c, wait)
+			return
+		}
 
+		userCollection := database.OpenCollection("users", client)
 
+		var user models.User
+		err := userCollection.FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
+		if err != nil || user.ResetOTPHash == "" || time.Now().After(user.ResetOTPExpiry) {
+			utils.RecordFailure(ctx, client, ipKey, utils.OTPIPPolicy)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset code"})
+			return
+		}
 
+		if err := bcrypt.CompareHashAndPassword([]byte(user.ResetOTPHash), []byte(req.OTP)); err != nil {
+			recordOTPGuessFailure(ctx, c, client, accountKey, ipKey)
+			if _, err := recordOTPFailure(ctx, userCollection, user.Id, "reset_otp", user.ResetOTPHash); err != nil {
+				log.Println("RESET PASSWORD: failed to record attempt:", err)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset code"})
+			return
+		}
+
+		hashedPassword, err := HashPassword(req.NewPassword)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
 
+		// Matching on the OTP hash makes the code single-use even if two
+		// resets race each other.
+		result, err := userCollection.UpdateOne(ctx,
+			bson.M{"_id": user.Id, "reset_otp_hash": user.ResetOTPHash},
+			bson.M{
+				"$set": bson.M{
+					"password":   hashedPassword,
+					"updated_at": time.Now(),
+				},
+				"$unset": bson.M{
+					"reset_otp_hash":     "",
+					"reset_otp_expiry":   "",
+					"reset_otp_attempts": "",
+				},
+			},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset code"})
+			return
+		}
+
+		utils.ResetAttempts(ctx, client, accountKey)
+
+		if err := utils.RevokeUserSessions(ctx, client, user.Id, "password_reset"); err != nil {
+			log.Println("RESET PASSWORD: failed to revoke sessions:", err)
+		}
+		if err := utils.RevokeUserTokens(ctx, client, user.Id.Hex(), "password_reset"); err != nil {
+			log.Println("RESET PASSWORD: failed to revoke tokens:", err)
+		}
+		disconnectUser(user.Id.Hex())
+
+		recordAudit(c, client, models.AuditEvent{Type: utils.AuditPasswordReset, UserID: auditUser(user.Id), Email: user.Email})
+
+		clearAuthCookies(c)
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Password reset successfully. Please log in.",
+		})
+	}
+}
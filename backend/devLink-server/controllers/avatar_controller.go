@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// UploadAvatar replaces the caller's profile image with the image sent as the
+// "avatar" field of a multipart form. See utils.ProcessAvatar for what is done
+// to the file before it is stored.
+func UploadAvatar(client *mongo.Client, store utils.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		// Leave room for the multipart framing around the file.
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.AvatarMaxBytes+64<<10)
+
+		file, header, err := c.Request.FormFile("avatar")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				respondAvatarTooLarge(c)
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Avatar file is required"})
+			return
+		}
+		defer file.Close()
+
+		if header.Size > utils.AvatarMaxBytes {
+			respondAvatarTooLarge(c)
+			return
+		}
+
+		data, err := io.ReadAll(io.LimitReader(file, utils.AvatarMaxBytes+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read avatar"})
+			return
+		}
+		if len(data) > utils.AvatarMaxBytes {
+			respondAvatarTooLarge(c)
+			return
+		}
+
+		renditions, err := utils.ProcessAvatar(data)
+		if errors.Is(err, utils.ErrUnsupportedImage) || errors.Is(err, utils.ErrImageTooLarge) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "AVATAR_INVALID"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process avatar"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		keys, err := storeAvatar(ctx, store, user.Id, renditions)
+		if err != nil {
+			log.Println("UPLOAD AVATAR: failed to store avatar:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store avatar"})
+			return
+		}
+
+		sizes := gin.H{}
+		for i, r := range renditions {
+			sizes[strconv.Itoa(r.Size)] = mediaURL(keys[i])
+		}
+
+		profileImage := mediaURL(keys[0])
+		now := time.Now()
+
+		_, err = userCollection.UpdateOne(ctx, bson.M{"_id": user.Id}, bson.M{"$set": bson.M{
+			"profile_image": profileImage,
+			"avatar_keys":   keys,
+			"updated_at":    now,
+		}})
+		if err != nil {
+			utils.DeleteBlobs(ctx, store, keys)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+
+		recordProfileChange(ctx, client, user.Id, map[string]models.FieldChange{
+			"profile_image": {From: user.ProfileImage, To: profileImage},
+		}, now)
+
+		if err := utils.DeleteBlobs(ctx, store, user.AvatarKeys); err != nil {
+			log.Println("UPLOAD AVATAR: failed to delete previous avatar:", err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"profile_image": profileImage, "sizes": sizes})
+	}
+}
+
+// storeAvatar saves the renditions of a processed avatar and returns their
+// keys, in the same order. Every avatar gets fresh keys so the files can be
+// cached forever. Nothing is left behind on failure.
+func storeAvatar(ctx context.Context, store utils.BlobStore, userID bson.ObjectID, renditions []utils.AvatarImage) ([]string, error) {
+	version := bson.NewObjectID().Hex()
+	keys := make([]string, 0, len(renditions))
+	for _, r := range renditions {
+		key := fmt.Sprintf("avatars/%s/%s/%d.%s", userID.Hex(), version, r.Size, r.Ext)
+		if err := store.Put(ctx, key, r.Data, r.ContentType); err != nil {
+			utils.DeleteBlobs(ctx, store, keys)
+			return nil, fmt.Errorf("storing %s: %w", key, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// avatarHTTPClient fetches avatars from OAuth providers.
+var avatarHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// importAvatar copies the picture at avatarURL, such as the one an OAuth
+// provider returned, into the blob store so that no avatar is hot-linked. It
+// returns the new profile image and the blob keys.
+func importAvatar(ctx context.Context, store utils.BlobStore, userID bson.ObjectID, avatarURL string) (string, []string, error) {
+	u, err := url.Parse(avatarURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", nil, fmt.Errorf("refusing avatar URL %q", avatarURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	resp, err := avatarHTTPClient.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("fetching avatar: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, utils.AvatarMaxBytes+1))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) > utils.AvatarMaxBytes {
+		return "", nil, utils.ErrImageTooLarge
+	}
+
+	renditions, err := utils.ProcessAvatar(data)
+	if err != nil {
+		return "", nil, err
+	}
+	keys, err := storeAvatar(ctx, store, userID, renditions)
+	if err != nil {
+		return "", nil, err
+	}
+	return mediaURL(keys[0]), keys, nil
+}
+
+// RemoveAvatar deletes the caller's uploaded avatar and goes back to the
+// generated initials avatar.
+func RemoveAvatar(client *mongo.Client, store utils.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		profileImage := generatedAvatarURL(user.UserName)
+		if user.ProfileImage == profileImage && len(user.AvatarKeys) == 0 {
+			c.JSON(http.StatusOK, gin.H{"profile_image": profileImage})
+			return
+		}
+
+		now := time.Now()
+		_, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.Id}, bson.M{
+			"$set":   bson.M{"profile_image": profileImage, "updated_at": now},
+			"$unset": bson.M{"avatar_keys": ""},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+
+		recordProfileChange(ctx, client, user.Id, map[string]models.FieldChange{
+			"profile_image": {From: user.ProfileImage, To: profileImage},
+		}, now)
+
+		if err := utils.DeleteBlobs(ctx, store, user.AvatarKeys); err != nil {
+			log.Println("REMOVE AVATAR: failed to delete avatar:", err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"profile_image": profileImage})
+	}
+}
+
+// GetInitialsAvatar serves the generated avatar for ?seed=.
+func GetInitialsAvatar() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=86400")
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Data(http.StatusOK, "image/svg+xml", utils.InitialsAvatarSVG(c.Query("seed")))
+	}
+}
+
+// ServeMedia streams a public file from the blob store. Keys are never
+// reused, so responses may be cached indefinitely. Only avatars are public:
+// data exports share the store and are served by DownloadDataExport.
+func ServeMedia(store utils.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("key"), "/")
+		if !utils.ValidBlobKey(key) || !strings.HasPrefix(key, "avatars/") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		body, contentType, err := store.Get(ctx, key)
+		if errors.Is(err, utils.ErrBlobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+		if err != nil {
+			log.Println("MEDIA: failed to read", key+":", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+			return
+		}
+		defer body.Close()
+
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.DataFromReader(http.StatusOK, -1, contentType, body, nil)
+	}
+}
+
+func mediaURL(key string) string {
+	return utils.PublicAPIURL("/media/" + key)
+}
+
+func respondAvatarTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error": fmt.Sprintf("Avatar must be at most %d MB", utils.AvatarMaxBytes>>20),
+		"code":  "AVATAR_TOO_LARGE",
+	})
+}
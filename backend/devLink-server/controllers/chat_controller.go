@@ -3,12 +3,14 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 
 	"time"
 
 	"github.com/ayushmehta03/devLink-backend/database"
 	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -16,7 +18,7 @@ import (
 )
 
 
-func SendChatRequest(client *mongo.Client)gin.HandlerFunc{
+func SendChatRequest(client *mongo.Client,mailer utils.Mailer)gin.HandlerFunc{
 	return func(c *gin.Context){
 
 
@@ -88,12 +90,35 @@ func SendChatRequest(client *mongo.Client)gin.HandlerFunc{
 			return 
 		}
 
+		go notifyChatRequest(client,mailer,request)
+
 		c.JSON(http.StatusCreated,gin.H{"message":"Chat request sent "})
 
 	}
 }
 
 
+// notifyChatRequest emails the receiver of a new chat request.
+func notifyChatRequest(client *mongo.Client, mailer utils.Mailer, request models.ChatRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	userCol := database.OpenCollection("users", client)
+
+	var sender, receiver models.User
+	if err := userCol.FindOne(ctx, bson.M{"_id": request.SenderID}).Decode(&sender); err != nil {
+		return
+	}
+	if err := userCol.FindOne(ctx, bson.M{"_id": request.ReceiverID}).Decode(&receiver); err != nil {
+		return
+	}
+
+	if err := utils.SendChatRequestEmail(ctx, mailer, "chat_request:"+request.ID.Hex(), receiver.Email, receiver.Locale, sender.UserName); err != nil {
+		log.Println("CHAT REQUEST EMAIL FAILED:", err)
+	}
+}
+
+
 func ReceiveChatRequest(client *mongo.Client)gin.HandlerFunc{
 	return func(c *gin.Context){
 
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCSRFToken returns the caller's CSRF token, issuing a new csrf_token
+// cookie if there is none yet. The client sends it back in the X-CSRF-Token
+// header on every state-changing request.
+func GetCSRFToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := c.Cookie(utils.CSRFCookie)
+		if err != nil || token == "" {
+			token, err = utils.GenerateOpaqueToken()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate CSRF token"})
+				return
+			}
+		}
+
+		// Not httpOnly: the client has to be able to read it back.
+		c.SetSameSite(http.SameSiteStrictMode)
+		c.SetCookie(utils.CSRFCookie, token, int(utils.RefreshTokenTTL.Seconds()), "/", "localhost", false, false)
+
+		c.JSON(http.StatusOK, gin.H{"csrf_token": token})
+	}
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ListEmailTemplates lists the transactional email templates and the locales
+// they are translated to.
+func ListEmailTemplates() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"templates":      utils.EmailTemplateNames,
+			"locales":        utils.SupportedLocales,
+			"default_locale": utils.DefaultLocale,
+		})
+	}
+}
+
+// PreviewEmailTemplate renders a template with sample data so that designers
+// can check it without triggering a real email. ?format=html (the default),
+// text or json selects what is returned.
+func PreviewEmailTemplate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+
+		data, ok := utils.SampleEmailData(name)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown email template"})
+			return
+		}
+
+		locale := c.DefaultQuery("locale", utils.DefaultLocale)
+
+		msg, err := utils.RenderEmail(name, locale, data)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render template", "details": err.Error()})
+			return
+		}
+
+		switch c.DefaultQuery("format", "html") {
+		case "html":
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(msg.HTML))
+		case "text":
+			c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte("Subject: "+msg.Subject+"\n\n"+msg.Text))
+		case "json":
+			c.JSON(http.StatusOK, gin.H{
+				"template": name,
+				"locale":   utils.MatchLocale(locale),
+				"subject":  msg.Subject,
+				"text":     msg.Text,
+				"html":     msg.HTML,
+			})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be html, text or json"})
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ListOutboundEmails lists queued, sent and dead-lettered emails, newest
+// first, optionally filtered by ?status= and ?to=.
+func ListOutboundEmails(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := bson.M{}
+		if status := c.Query("status"); status != "" {
+			filter["status"] = status
+		}
+		if to := c.Query("to"); to != "" {
+			filter["to"] = to
+		}
+
+		page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+		if page < 1 {
+			page = 1
+		}
+		const pageSize = 50
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := database.OpenCollection("outbound_emails", client).Find(
+			ctx,
+			filter,
+			options.Find().
+				SetSort(bson.D{{Key: "created_at", Value: -1}}).
+				SetSkip((page-1)*pageSize).
+				SetLimit(pageSize).
+				SetProjection(bson.M{"text": 0, "html": 0}),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch emails"})
+			return
+		}
+
+		emails := []models.OutboundEmail{}
+		if err := cursor.All(ctx, &emails); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse emails"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"emails": emails, "page": page})
+	}
+}
+
+// GetOutboundEmail returns the delivery status of one email.
+func GetOutboundEmail(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		emailId, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email id"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var email models.OutboundEmail
+		err = database.OpenCollection("outbound_emails", client).FindOne(ctx, bson.M{"_id": emailId}).Decode(&email)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Email not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"email": email})
+	}
+}
+
+// RetryOutboundEmail puts a dead-lettered email back into the queue with a
+// fresh attempt budget. Emails dead-lettered since bodies are discarded
+// cannot be retried; whoever asked for them has to request them again.
+func RetryOutboundEmail(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		emailId, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email id"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		col := database.OpenCollection("outbound_emails", client)
+
+		result, err := col.UpdateOne(
+			ctx,
+			bson.M{
+				"_id":    emailId,
+				"status": models.EmailDead,
+				"$or": bson.A{
+					bson.M{"text": bson.M{"$exists": true}},
+					bson.M{"html": bson.M{"$exists": true}},
+				},
+			},
+			bson.M{
+				"$set":   bson.M{"status": models.EmailQueued, "attempts": 0, "next_attempt_at": time.Now()},
+				"$unset": bson.M{"dead_at": "", "expires_at": ""},
+			},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to requeue email"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			count, err := col.CountDocuments(ctx, bson.M{"_id": emailId, "status": models.EmailDead})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to requeue email"})
+				return
+			}
+			if count > 0 {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "The body of this email was discarded and it cannot be resent",
+					"code":  "EMAIL_BODY_DISCARDED",
+				})
+				return
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "No dead-lettered email with this id"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Email requeued"})
+	}
+}
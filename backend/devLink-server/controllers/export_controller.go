@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// RequestDataExport queues an archive of the caller's data. The archive is
+// built by workers.RunDataExports, which emails a download link when done.
+func RequestDataExport(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		export := models.DataExport{
+			ID:          bson.NewObjectID(),
+			UserID:      userObjId,
+			Status:      models.ExportPending,
+			Active:      true,
+			RequestedAt: time.Now(),
+		}
+
+		_, err := database.OpenCollection("data_exports", client).InsertOne(ctx, export)
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "An export is already in progress"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request export"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Export requested. We will email you a download link when it is ready.",
+			"export":  export,
+		})
+	}
+}
+
+func ListDataExports(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := database.OpenCollection("data_exports", client).Find(
+			ctx,
+			bson.M{"user_id": userObjId},
+			options.Find().SetSort(bson.D{{Key: "requested_at", Value: -1}}).SetLimit(20),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exports"})
+			return
+		}
+
+		exports := []models.DataExport{}
+		if err := cursor.All(ctx, &exports); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse exports"})
+			return
+		}
+
+		c.JSON(http.StatusOK, exports)
+	}
+}
+
+// DownloadDataExport serves a finished archive. The token from the emailed link
+// is the only credential, so the link works without a session.
+func DownloadDataExport(client *mongo.Client, store utils.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exportId, err := bson.ObjectIDFromHex(c.Param("id"))
+		token := c.Query("token")
+		if err != nil || token == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var export models.DataExport
+		err = database.OpenCollection("data_exports", client).FindOne(ctx, bson.M{"_id": exportId}).Decode(&export)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch export"})
+			return
+		}
+
+		if export.DownloadTokenHash == "" ||
+			subtle.ConstantTimeCompare([]byte(export.DownloadTokenHash), []byte(utils.HashToken(token))) != 1 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+			return
+		}
+
+		if export.Status != models.ExportReady || export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) || export.BlobKey == "" {
+			c.JSON(http.StatusGone, gin.H{"error": "This download link has expired"})
+			return
+		}
+
+		// The archive can be large; stream it under the request's context
+		// rather than the 10 second lookup timeout.
+		body, _, err := store.Get(c.Request.Context(), export.BlobKey)
+		if errors.Is(err, utils.ErrBlobNotFound) {
+			c.JSON(http.StatusGone, gin.H{"error": "This download link has expired"})
+			return
+		}
+		if err != nil {
+			log.Println("EXPORT DOWNLOAD: failed to read", export.BlobKey+":", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch export"})
+			return
+		}
+		defer body.Close()
+
+		filename := "devlink-export-" + export.CompletedAt.UTC().Format("2006-01-02") + ".zip"
+		c.Header("Cache-Control", "no-store")
+		c.DataFromReader(http.StatusOK, export.SizeBytes, "application/zip", body, map[string]string{
+			"Content-Disposition": `attachment; filename="` + filename + `"`,
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// followPageSize is the length of one page of a follower or following list.
+const followPageSize = 50
+
+// FollowUser makes the caller follow the user :id. Following someone already
+// followed succeeds without changing anything.
+func FollowUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObjId, _ := bson.ObjectIDFromHex(c.GetString("user_id"))
+
+		targetId, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+			return
+		}
+		if targetId == userObjId {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot follow yourself", "code": "CANNOT_FOLLOW_SELF"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var target models.User
+		err = database.OpenCollection("users", client).FindOne(ctx, bson.M{"_id": targetId}).Decode(&target)
+		if err != nil || utils.AccountRestriction(target) != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		created, err := utils.Follow(ctx, client, userObjId, targetId)
+		if errors.Is(err, utils.ErrSelfFollow) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot follow yourself", "code": "CANNOT_FOLLOW_SELF"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
+			return
+		}
+
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		c.JSON(status, gin.H{"following": true})
+	}
+}
+
+// UnfollowUser makes the caller stop following the user :id.
+func UnfollowUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObjId, _ := bson.ObjectIDFromHex(c.GetString("user_id"))
+
+		targetId, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if _, err := utils.Unfollow(ctx, client, userObjId, targetId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"following": false})
+	}
+}
+
+// ListFollowers lists who follows the user :id, newest first.
+func ListFollowers(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listFollows(c, client, "followee_id", func(f models.Follow) bson.ObjectID { return f.FollowerID })
+	}
+}
+
+// ListFollowing lists whom the user :id follows, newest first.
+func ListFollowing(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listFollows(c, client, "follower_id", func(f models.Follow) bson.ObjectID { return f.FolloweeID })
+	}
+}
+
+// listFollows pages through the edges whose field is :id and responds with
+// the users at their other end. Lists can be huge, so rather than ?page= they
+// page with ?before=<next_cursor>, which stays an index seek however deep the
+// client goes.
+func listFollows(c *gin.Context, client *mongo.Client, field string, other func(models.Follow) bson.ObjectID) {
+	userObjId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	filter := bson.M{field: userObjId}
+	if before := c.Query("before"); before != "" {
+		cursorId, err := bson.ObjectIDFromHex(before)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		filter["_id"] = bson.M{"$lt": cursorId}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := database.OpenCollection("follows", client).Find(
+		ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{Key: "_id", Value: -1}}).
+			SetLimit(followPageSize),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch follows"})
+		return
+	}
+
+	var follows []models.Follow
+	if err := cursor.All(ctx, &follows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse follows"})
+		return
+	}
+
+	ids := make([]bson.ObjectID, 0, len(follows))
+	for _, f := range follows {
+		ids = append(ids, other(f))
+	}
+
+	userCursor, err := database.OpenCollection("users", client).Find(
+		ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"name": 1, "handle": 1, "profile_image": 1, "suspension": 1, "ban": 1}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	var users []models.User
+	if err := userCursor.All(ctx, &users); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse users"})
+		return
+	}
+
+	byId := make(map[bson.ObjectID]models.User, len(users))
+	for _, u := range users {
+		byId[u.Id] = u
+	}
+
+	items := []gin.H{}
+	for _, f := range follows {
+		u, ok := byId[other(f)]
+		if !ok || utils.AccountRestriction(u) != nil {
+			continue
+		}
+		items = append(items, gin.H{
+			"id":            u.Id.Hex(),
+			"name":          u.UserName,
+			"handle":        u.Handle,
+			"profile_image": u.ProfileImage,
+			"followed_at":   f.CreatedAt,
+		})
+	}
+
+	nextCursor := ""
+	if len(follows) == followPageSize {
+		nextCursor = follows[len(follows)-1].ID.Hex()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": items, "next_cursor": nextCursor})
+}
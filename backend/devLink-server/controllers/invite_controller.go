@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Limits on invites minted by regular users. Holders of
+// utils.PermInvitesManage get the staff limits instead.
+const (
+	defaultInviteLifetimeDays = 7
+	maxInviteLifetimeDays     = 30
+	maxInviteUses             = 10
+	maxActiveInvitesPerUser   = 10
+
+	staffMaxInviteLifetimeDays = 365
+	staffMaxInviteUses         = 1000
+)
+
+// GetRegistrationInfo tells the sign-up form whether to ask for an invite code.
+func GetRegistrationInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mode := utils.RegistrationMode()
+
+		response := gin.H{"mode": mode, "invite_required": mode == utils.RegistrationInvite}
+		if mode == utils.RegistrationDomain {
+			response["allowed_domains"] = utils.RegistrationDomains()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CreateInvite mints an invite code. The code is only ever returned here.
+func CreateInvite(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req struct {
+			Note          string `json:"note"`
+			MaxUses       int    `json:"max_uses"`
+			ExpiresInDays int    `json:"expires_in_days"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		staff := utils.HasPermission(c.GetString("role"), utils.PermInvitesManage)
+		usesLimit, lifetimeLimit := maxInviteUses, maxInviteLifetimeDays
+		if staff {
+			usesLimit, lifetimeLimit = staffMaxInviteUses, staffMaxInviteLifetimeDays
+		}
+
+		req.Note = strings.TrimSpace(req.Note)
+		if len(req.Note) > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Note must be at most 200 characters"})
+			return
+		}
+		if req.MaxUses == 0 {
+			req.MaxUses = 1
+		}
+		if req.MaxUses < 1 || req.MaxUses > usesLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_uses must be between 1 and " + strconv.Itoa(usesLimit)})
+			return
+		}
+		if req.ExpiresInDays == 0 {
+			req.ExpiresInDays = defaultInviteLifetimeDays
+		}
+		if req.ExpiresInDays < 1 || req.ExpiresInDays > lifetimeLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must be between 1 and " + strconv.Itoa(lifetimeLimit)})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		inviteCol := database.OpenCollection("invites", client)
+
+		if !staff {
+			count, err := inviteCol.CountDocuments(ctx, bson.M{
+				"created_by": userObjId,
+				"revoked_at": bson.M{"$exists": false},
+				"expires_at": bson.M{"$gt": time.Now()},
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+				return
+			}
+			if count >= maxActiveInvitesPerUser {
+				c.JSON(http.StatusConflict, gin.H{"error": "Too many active invites, revoke one first"})
+				return
+			}
+		}
+
+		code, err := utils.GenerateInviteCode()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+			return
+		}
+
+		invite := models.Invite{
+			ID:          bson.NewObjectID(),
+			CreatedBy:   userObjId,
+			Note:        req.Note,
+			Prefix:      code[:4],
+			CodeHash:    utils.HashInviteCode(code),
+			MaxUses:     req.MaxUses,
+			Redemptions: []models.InviteRedemption{},
+			CreatedAt:   time.Now(),
+			ExpiresAt:   time.Now().AddDate(0, 0, req.ExpiresInDays),
+		}
+
+		if _, err := inviteCol.InsertOne(ctx, invite); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+			return
+		}
+
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditInviteCreated,
+			UserID:   auditUser(userObjId),
+			Metadata: bson.M{"invite_id": invite.ID.Hex(), "max_uses": invite.MaxUses},
+		})
+
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Invite created. Copy the code now, it will not be shown again.",
+			"code":    code,
+			"invite":  invite,
+		})
+	}
+}
+
+// ListInvites returns the invites the caller created, newest first.
+func ListInvites(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+		listInvites(c, client, bson.M{"created_by": userObjId})
+	}
+}
+
+// ListAllInvites lets admins see every invite, optionally filtered by
+// ?created_by=.
+func ListAllInvites(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := bson.M{}
+		if createdBy := c.Query("created_by"); createdBy != "" {
+			userObjId, err := bson.ObjectIDFromHex(createdBy)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+				return
+			}
+			filter["created_by"] = userObjId
+		}
+		listInvites(c, client, filter)
+	}
+}
+
+func listInvites(c *gin.Context, client *mongo.Client, filter bson.M) {
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	const pageSize = 50
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := database.OpenCollection("invites", client).Find(
+		ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip((page-1)*pageSize).
+			SetLimit(pageSize),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invites"})
+		return
+	}
+
+	invites := []models.Invite{}
+	if err := cursor.All(ctx, &invites); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse invites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invites": invites, "page": page})
+}
+
+// RevokeInvite stops an invite from being used again. Users can revoke their
+// own invites; holders of utils.PermInvitesManage can revoke any.
+func RevokeInvite(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		inviteObjId, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite id"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		filter := bson.M{"_id": inviteObjId, "revoked_at": bson.M{"$exists": false}}
+		if !utils.HasPermission(c.GetString("role"), utils.PermInvitesManage) {
+			filter["created_by"] = userObjId
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		result, err := database.OpenCollection("invites", client).UpdateOne(
+			ctx,
+			filter,
+			bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+			return
+		}
+
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditInviteRevoked,
+			UserID:   auditUser(userObjId),
+			Metadata: bson.M{"invite_id": inviteObjId.Hex()},
+		})
+
+		c.JSON(http.StatusOK, gin.H{"message": "Invite revoked"})
+	}
+}
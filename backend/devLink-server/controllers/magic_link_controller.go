@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// magicNonceCookie binds a login link to the browser that requested it. It is
+// scoped to the magic-link endpoints.
+const magicNonceCookie = "magic_nonce"
+
+func setMagicNonceCookie(c *gin.Context, nonce string, maxAge int) {
+	c.SetCookie(magicNonceCookie, nonce, maxAge, "/auth/magic-link", "localhost", false, true)
+}
+
+// RequestMagicLink emails a single-use login link. Like ForgotPassword it
+// answers the same way whether or not the email is registered.
+func RequestMagicLink(client *mongo.Client, mailer utils.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		accountKey := "magic:account:" + strings.ToLower(req.Email)
+		ipKey := "magic:ip:" + c.ClientIP()
+
+		if wait, err := utils.CheckLockout(ctx, client, accountKey, ipKey); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send link"})
+			return
+		} else if wait > 0 {
+			respondTooManyAttempts(c, wait)
+			return
+		}
+
+		utils.RecordFailure(ctx, client, accountKey, utils.MagicLinkRequestPolicy)
+		utils.RecordFailure(ctx, client, ipKey, utils.LoginIPPolicy)
+
+		nonce, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send link"})
+			return
+		}
+
+		// The cookie is set for unknown emails too so the response does not
+		// reveal whether an account exists.
+		setMagicNonceCookie(c, nonce, int(utils.MagicLinkTTL.Seconds()))
+
+		response := gin.H{
+			"message": "If an account exists for this email, a sign-in link has been sent.",
+		}
+
+		var user models.User
+		if err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"email": req.Email}).Decode(&user); err != nil {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		token, expiresAt, err := utils.CreateMagicLink(ctx, client, user.Id, nonce, c.ClientIP())
+		if err != nil {
+			log.Println("MAGIC LINK: failed to create link:", err)
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		loginURL := utils.FrontendURL("/magic-link?token=" + url.QueryEscape(token))
+
+		go func(email, locale string) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			if err := utils.SendMagicLinkEmail(ctx, mailer, "magic:"+utils.HashToken(token), email, locale, loginURL, expiresAt); err != nil {
+				log.Println("MAGIC LINK EMAIL FAILED:", err)
+			}
+		}(user.Email, user.Locale)
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ConsumeMagicLink signs the user in with a link sent by RequestMagicLink. The
+// request must come from the browser holding the matching nonce cookie.
+func ConsumeMagicLink(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var req struct {
+			Token string `json:"token"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		ipKey := "magic:consume:ip:" + c.ClientIP()
+
+		if wait, err := utils.CheckLockout(ctx, client, ipKey); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+			return
+		} else if wait > 0 {
+			respondTooManyAttempts(c, wait)
+			return
+		}
+
+		nonce, err := c.Cookie(magicNonceCookie)
+		if err != nil || nonce == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Open the link in the browser you requested it from",
+				"code":  "MAGIC_LINK_WRONG_BROWSER",
+			})
+			return
+		}
+
+		userObjId, err := utils.ConsumeMagicLink(ctx, client, req.Token, nonce)
+		if err != nil {
+			switch {
+			case errors.Is(err, utils.ErrMagicLinkBrowser):
+				utils.RecordFailure(ctx, client, ipKey, utils.OTPIPPolicy)
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "Open the link in the browser you requested it from",
+					"code":  "MAGIC_LINK_WRONG_BROWSER",
+				})
+			case errors.Is(err, utils.ErrInvalidMagicLink):
+				utils.RecordFailure(ctx, client, ipKey, utils.OTPIPPolicy)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "This link is invalid, expired or already used"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+			}
+			return
+		}
+
+		setMagicNonceCookie(c, "", -1)
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		if rejectRestricted(c, client, user) {
+			return
+		}
+
+		// Opening the link proves the user controls the mailbox. Nobody had
+		// proved that before, so whoever set the password may not be its
+		// owner; drop it, along with any reset code they asked for, as a
+		// provider login does.
+		if !user.IsVerified {
+			_, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.Id}, bson.M{
+				"$set": bson.M{"is_verified": true, "updated_at": time.Now()},
+				"$unset": bson.M{
+					"password":           "",
+					"otp_hash":           "",
+					"otp_expiry":         "",
+					"otp_attempts":       "",
+					"reset_otp_hash":     "",
+					"reset_otp_expiry":   "",
+					"reset_otp_attempts": "",
+				},
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+				return
+			}
+			user.IsVerified = true
+			user.Password = ""
+		}
+
+		if user.TOTPEnabled {
+			mfaToken, err := utils.GeneratePurposeToken(user.Id.Hex(), "mfa", mfaChallengeTTL)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+				return
+			}
+
+			// Like a provider login, the challenge goes to the login page in
+			// a cookie rather than through the URL.
+			setMFACookie(c, mfaToken, int(mfaChallengeTTL.Seconds()))
+			c.JSON(http.StatusOK, gin.H{
+				"message":      "Two-factor authentication required",
+				"code":         "MFA_REQUIRED",
+				"mfa_required": true,
+				"expires_in":   int(mfaChallengeTTL.Seconds()),
+			})
+			return
+		}
+
+		if err := startSession(ctx, c, client, user, false); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		recordLogin(c, client, user, "magic_link")
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful",
+			"user": gin.H{
+				"id":    user.Id.Hex(),
+				"name":  user.UserName,
+				"email": user.Email,
+				"role":  user.Role,
+			},
+		})
+	}
+}
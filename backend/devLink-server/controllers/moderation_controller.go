@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// rejectRestricted answers 403 and records a failed login if user is banned
+// or suspended. It reports whether it did.
+func rejectRestricted(c *gin.Context, client *mongo.Client, user models.User) bool {
+	restricted := utils.AccountRestriction(user)
+	if restricted == nil {
+		return false
+	}
+
+	recordAudit(c, client, models.AuditEvent{
+		Type:   utils.AuditLoginFailed,
+		UserID: auditUser(user.Id),
+		Email:  user.Email,
+		Reason: strings.ToLower(restricted.Code),
+	})
+	c.JSON(http.StatusForbidden, restricted.Body())
+	return true
+}
+
+// lockOut ends every session and socket of userID so that a suspension or ban
+// takes effect immediately rather than when the access token expires.
+func lockOut(ctx context.Context, client *mongo.Client, userID bson.ObjectID, reason string) {
+	if err := utils.RevokeUserSessions(ctx, client, userID, reason); err != nil {
+		log.Println("MODERATION: failed to revoke sessions:", err)
+	}
+	if err := utils.RevokeUserTokens(ctx, client, userID.Hex(), reason); err != nil {
+		log.Println("MODERATION: failed to revoke tokens:", err)
+	}
+	disconnectUser(userID.Hex())
+}
+
+// loadModerationTarget reads the user named by :id and checks that the caller
+// may act on them: nobody can moderate themselves, and only admins can
+// moderate staff.
+func loadModerationTarget(ctx context.Context, c *gin.Context, client *mongo.Client) (models.User, bson.ObjectID, bool) {
+	var target models.User
+
+	targetId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return target, bson.ObjectID{}, false
+	}
+
+	actorId, _ := bson.ObjectIDFromHex(c.GetString("user_id"))
+	if targetId == actorId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot moderate your own account"})
+		return target, actorId, false
+	}
+
+	if err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"_id": targetId}).Decode(&target); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return target, actorId, false
+	}
+
+	if target.Role != utils.RoleUser && !utils.HasPermission(c.GetString("role"), utils.PermUsersBan) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can moderate staff accounts"})
+		return target, actorId, false
+	}
+
+	return target, actorId, true
+}
+
+// SuspendUser locks a user out until the optional end date, or until the
+// suspension is lifted.
+func SuspendUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Reason string     `json:"reason"`
+			Until  *time.Time `json:"until"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		req.Reason = strings.TrimSpace(req.Reason)
+		if req.Reason == "" || len(req.Reason) > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A reason of at most 500 characters is required"})
+			return
+		}
+		if req.Until != nil && !req.Until.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "until must be in the future"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		target, actorId, ok := loadModerationTarget(ctx, c, client)
+		if !ok {
+			return
+		}
+
+		suspension := models.Suspension{
+			Reason: req.Reason,
+			Until:  req.Until,
+			By:     actorId,
+			At:     time.Now(),
+		}
+
+		_, err := database.OpenCollection("users", client).UpdateOne(ctx, bson.M{"_id": target.Id}, bson.M{
+			"$set": bson.M{"suspension": suspension, "updated_at": time.Now()},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+			return
+		}
+
+		lockOut(ctx, client, target.Id, "suspended")
+
+		metadata := bson.M{"reason": req.Reason}
+		if req.Until != nil {
+			metadata["until"] = *req.Until
+		}
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditUserSuspended,
+			UserID:   auditUser(target.Id),
+			ActorID:  auditUser(actorId),
+			Email:    target.Email,
+			Metadata: metadata,
+		})
+
+		// The restriction already holds; repeating the action retries this.
+		if err := utils.SetAuthorRestricted(ctx, client, target.Id, true); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hide the user's posts"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "User suspended", "suspension": suspension})
+	}
+}
+
+// UnsuspendUser lifts a user's suspension.
+func UnsuspendUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		target, actorId, ok := loadModerationTarget(ctx, c, client)
+		if !ok {
+			return
+		}
+		if target.Suspension == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User is not suspended"})
+			return
+		}
+
+		// Unflag the posts first: if that fails the suspension is still in
+		// place and lifting it can simply be retried.
+		target.Suspension = nil
+		if err := utils.SetAuthorRestricted(ctx, client, target.Id, utils.AccountRestriction(target) != nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift suspension"})
+			return
+		}
+
+		_, err := database.OpenCollection("users", client).UpdateOne(ctx, bson.M{"_id": target.Id}, bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"suspension": ""},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift suspension"})
+			return
+		}
+
+		recordAudit(c, client, models.AuditEvent{
+			Type:    utils.AuditUserUnsuspended,
+			UserID:  auditUser(target.Id),
+			ActorID: auditUser(actorId),
+			Email:   target.Email,
+		})
+
+		c.JSON(http.StatusOK, gin.H{"message": "Suspension lifted"})
+	}
+}
+
+// BanUser locks a user out permanently.
+func BanUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		req.Reason = strings.TrimSpace(req.Reason)
+		if req.Reason == "" || len(req.Reason) > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A reason of at most 500 characters is required"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		target, actorId, ok := loadModerationTarget(ctx, c, client)
+		if !ok {
+			return
+		}
+
+		ban := models.Ban{
+			Reason: req.Reason,
+			By:     actorId,
+			At:     time.Now(),
+		}
+
+		_, err := database.OpenCollection("users", client).UpdateOne(ctx, bson.M{"_id": target.Id}, bson.M{
+			"$set": bson.M{"ban": ban, "updated_at": time.Now()},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban user"})
+			return
+		}
+
+		lockOut(ctx, client, target.Id, "banned")
+
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditUserBanned,
+			UserID:   auditUser(target.Id),
+			ActorID:  auditUser(actorId),
+			Email:    target.Email,
+			Metadata: bson.M{"reason": req.Reason},
+		})
+
+		if err := utils.SetAuthorRestricted(ctx, client, target.Id, true); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hide the user's posts"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "User banned", "ban": ban})
+	}
+}
+
+// UnbanUser reverses a ban.
+func UnbanUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		target, actorId, ok := loadModerationTarget(ctx, c, client)
+		if !ok {
+			return
+		}
+		if target.Ban == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User is not banned"})
+			return
+		}
+
+		target.Ban = nil
+		if err := utils.SetAuthorRestricted(ctx, client, target.Id, utils.AccountRestriction(target) != nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift ban"})
+			return
+		}
+
+		_, err := database.OpenCollection("users", client).UpdateOne(ctx, bson.M{"_id": target.Id}, bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"ban": ""},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift ban"})
+			return
+		}
+
+		recordAudit(c, client, models.AuditEvent{
+			Type:    utils.AuditUserUnbanned,
+			UserID:  auditUser(target.Id),
+			ActorID: auditUser(actorId),
+			Email:   target.Email,
+		})
+
+		c.JSON(http.StatusOK, gin.H{"message": "Ban lifted"})
+	}
+}
@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+func oauthFail(c *gin.Context, reason string) {
+	c.SetCookie("oauth_state", "", -1, "/auth/oauth", "localhost", false, true)
+	c.SetCookie("oauth_verifier", "", -1, "/auth/oauth", "localhost", false, true)
+	c.SetCookie("oauth_invite", "", -1, "/auth/oauth", "localhost", false, true)
+	c.Redirect(http.StatusFound, utils.FrontendURL("/login?error="+url.QueryEscape(reason)))
+}
+
+// errInviteRequired is returned by findOrCreateOAuthUser when a new account
+// would need an invite code and none was given.
+var errInviteRequired = errors.New("an invite code is required to register")
+
+// OAuthLogin sends the browser to the provider's consent page. An ?invite=
+// code is kept for the callback in case the login creates an account.
+func OAuthLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provider, err := utils.OAuthProviderByName(c.Param("provider"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+			return
+		}
+
+		state, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+			return
+		}
+		verifier, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+			return
+		}
+
+		maxAge := int(oauthStateTTL.Seconds())
+		c.SetCookie("oauth_state", state, maxAge, "/auth/oauth", "localhost", false, true)
+		c.SetCookie("oauth_verifier", verifier, maxAge, "/auth/oauth", "localhost", false, true)
+		if invite := c.Query("invite"); invite != "" {
+			c.SetCookie("oauth_invite", utils.NormalizeInviteCode(invite), maxAge, "/auth/oauth", "localhost", false, true)
+		}
+
+		c.Redirect(http.StatusFound, provider.AuthCodeURL(state, verifier))
+	}
+}
+
+// OAuthCallback finishes the authorization-code flow, finds or creates the
+// matching user and signs them in.
+func OAuthCallback(client *mongo.Client, store utils.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provider, err := utils.OAuthProviderByName(c.Param("provider"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+			return
+		}
+
+		if c.Query("error") != "" {
+			oauthFail(c, "oauth_denied")
+			return
+		}
+
+		state, err := c.Cookie("oauth_state")
+		if err != nil || state == "" || c.Query("state") != state {
+			oauthFail(c, "oauth_state_mismatch")
+			return
+		}
+		verifier, err := c.Cookie("oauth_verifier")
+		if err != nil || verifier == "" {
+			oauthFail(c, "oauth_state_mismatch")
+			return
+		}
+
+		inviteCode, _ := c.Cookie("oauth_invite")
+
+		c.SetCookie("oauth_state", "", -1, "/auth/oauth", "localhost", false, true)
+		c.SetCookie("oauth_verifier", "", -1, "/auth/oauth", "localhost", false, true)
+		c.SetCookie("oauth_invite", "", -1, "/auth/oauth", "localhost", false, true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		accessToken, err := provider.Exchange(ctx, c.Query("code"), verifier)
+		if err != nil {
+			log.Println("OAUTH: code exchange failed:", err)
+			oauthFail(c, "oauth_failed")
+			return
+		}
+
+		profile, err := provider.FetchProfile(ctx, provider, accessToken)
+		if err != nil {
+			log.Println("OAUTH: fetching profile failed:", err)
+			oauthFail(c, "oauth_failed")
+			return
+		}
+
+		user, created, err := findOrCreateOAuthUser(ctx, client, store, provider.Name, profile, utils.MatchLocale(c.GetHeader("Accept-Language")), inviteCode)
+		if errors.Is(err, errInviteRequired) {
+			oauthFail(c, "invite_required")
+			return
+		}
+		if errors.Is(err, utils.ErrInvalidInvite) {
+			oauthFail(c, "invite_invalid")
+			return
+		}
+		if err != nil {
+			log.Println("OAUTH: linking user failed:", err)
+			oauthFail(c, "oauth_failed")
+			return
+		}
+
+		if created {
+			recordAudit(c, client, models.AuditEvent{
+				Type:     utils.AuditRegister,
+				UserID:   auditUser(user.Id),
+				Email:    user.Email,
+				Metadata: bson.M{"method": "oauth:" + provider.Name},
+			})
+		}
+
+		if restricted := utils.AccountRestriction(*user); restricted != nil {
+			recordAudit(c, client, models.AuditEvent{
+				Type:   utils.AuditLoginFailed,
+				UserID: auditUser(user.Id),
+				Email:  user.Email,
+				Reason: strings.ToLower(restricted.Code),
+			})
+			oauthFail(c, strings.ToLower(restricted.Code))
+			return
+		}
+
+		if user.TOTPEnabled {
+			mfaToken, err := utils.GeneratePurposeToken(user.Id.Hex(), "mfa", mfaChallengeTTL)
+			if err != nil {
+				oauthFail(c, "oauth_failed")
+				return
+			}
+			setMFACookie(c, mfaToken, int(mfaChallengeTTL.Seconds()))
+			c.Redirect(http.StatusFound, utils.FrontendURL("/login?mfa=1"))
+			return
+		}
+
+		if err := startSession(ctx, c, client, *user, false); err != nil {
+			oauthFail(c, "oauth_failed")
+			return
+		}
+
+		recordLogin(c, client, *user, "oauth:"+provider.Name)
+
+		c.Redirect(http.StatusFound, utils.FrontendURL("/dashboard"))
+	}
+}
+
+// findOrCreateOAuthUser returns the user linked to profile, linking or
+// creating one if needed. The bool reports whether a new account was made.
+// Creating one redeems inviteCode when the registration mode asks for it.
+// The provider's picture is copied into store for users who have none.
+func findOrCreateOAuthUser(ctx context.Context, client *mongo.Client, store utils.BlobStore, providerName string, profile *utils.OAuthProfile, locale, inviteCode string) (*models.User, bool, error) {
+	userCollection := database.OpenCollection("users", client)
+	identityKey := providerName + ":" + profile.Subject
+
+	var user models.User
+	err := userCollection.FindOne(ctx, bson.M{"identities.key": identityKey}).Decode(&user)
+	if err == nil {
+		return &user, false, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, err
+	}
+
+	if !profile.EmailVerified || profile.Email == "" {
+		return nil, false, errors.New("provider did not return a verified email")
+	}
+
+	identity := models.ExternalIdentity{
+		Key:      identityKey,
+		Provider: providerName,
+		Subject:  profile.Subject,
+		Username: profile.Username,
+		LinkedAt: time.Now(),
+	}
+
+	// Stored emails keep the case they were registered with, while some
+	// providers lowercase theirs. An exact match wins over a case-insensitive
+	// one should an address exist in two spellings.
+	err = userCollection.FindOne(ctx, bson.M{"email": profile.Email}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = userCollection.FindOne(ctx, bson.M{"email_lower": utils.NormalizeEmail(profile.Email)}).Decode(&user)
+	}
+	if err == nil {
+		set := bson.M{"updated_at": time.Now()}
+		unset := bson.M{}
+
+		if !user.IsVerified {
+			// Nobody proved ownership of this email before; whoever set the
+			// password may not be its owner, so the provider login wins.
+			set["is_verified"] = true
+			unset["password"] = ""
+			unset["otp_hash"] = ""
+			unset["otp_expiry"] = ""
+			unset["otp_attempts"] = ""
+		}
+		var avatarKeys []string
+		if profile.AvatarURL != "" && isGeneratedAvatar(user.ProfileImage) {
+			profileImage, keys, err := importAvatar(ctx, store, user.Id, profile.AvatarURL)
+			if err != nil {
+				log.Println("OAUTH: failed to import avatar:", err)
+			} else {
+				set["profile_image"] = profileImage
+				set["avatar_keys"] = keys
+				avatarKeys = keys
+			}
+		}
+
+		update := bson.M{
+			"$set":  set,
+			"$push": bson.M{"identities": identity},
+		}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+
+		if _, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.Id}, update); err != nil {
+			utils.DeleteBlobs(ctx, store, avatarKeys)
+			return nil, false, err
+		}
+
+		if err := userCollection.FindOne(ctx, bson.M{"_id": user.Id}).Decode(&user); err != nil {
+			return nil, false, err
+		}
+		return &user, false, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, err
+	}
+
+	seed := profile.Username
+	if seed == "" {
+		seed = profile.Name
+	}
+	userId := bson.NewObjectID()
+	handle, err := utils.SuggestHandle(ctx, client, seed, userId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	name := oauthDisplayName(profile.Name, profile.Username, handle)
+
+	avatarURL := generatedAvatarURL(name)
+	var avatarKeys []string
+	if profile.AvatarURL != "" {
+		if profileImage, keys, err := importAvatar(ctx, store, userId, profile.AvatarURL); err != nil {
+			log.Println("OAUTH: failed to import avatar:", err)
+		} else {
+			avatarURL, avatarKeys = profileImage, keys
+		}
+	}
+
+	var invite *models.Invite
+	if utils.InviteRequired(profile.Email) {
+		if inviteCode == "" {
+			return nil, false, errInviteRequired
+		}
+		invite, err = utils.RedeemInvite(ctx, client, inviteCode, userId, profile.Email)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	user = models.User{
+		Id:           userId,
+		UserId:       bson.NewObjectID().Hex(),
+		UserName:     name,
+		Handle:       handle,
+		HandleLower:  utils.NormalizeHandle(handle),
+		Email:        profile.Email,
+		EmailLower:   utils.NormalizeEmail(profile.Email),
+		Role:         utils.RoleUser,
+		Locale:       locale,
+		ProfileImage: avatarURL,
+		AvatarKeys:   avatarKeys,
+		IsVerified:   true,
+		Identities:   []models.ExternalIdentity{identity},
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if invite != nil {
+		user.InviteID = &invite.ID
+	}
+
+	if _, err := userCollection.InsertOne(ctx, user); err != nil {
+		if invite != nil {
+			utils.ReleaseInvite(ctx, client, invite.ID, userId)
+		}
+		utils.DeleteBlobs(ctx, store, avatarKeys)
+		return nil, false, err
+	}
+	return &user, true, nil
+}
+
+// oauthDisplayName picks the first candidate that fits the 5 to 22 characters
+// models.User allows for a name, cutting long ones short. Provider logins are
+// often shorter than that, e.g. "bob".
+func oauthDisplayName(candidates ...string) string {
+	for _, candidate := range candidates {
+		runes := []rune(strings.TrimSpace(candidate))
+		if len(runes) > 22 {
+			runes = []rune(strings.TrimSpace(string(runes[:22])))
+		}
+		if len(runes) >= 5 {
+			return string(runes)
+		}
+	}
+	return "DevLink member"
+}
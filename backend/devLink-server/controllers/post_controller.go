@@ -11,6 +11,7 @@ import (
 
 	"github.com/ayushmehta03/devLink-backend/database"
 	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -20,6 +21,7 @@ import (
 type PostAuthor struct {
 	ID           bson.ObjectID `json:"id"`
 	Username     string        `json:"username"`
+	Handle       string        `json:"handle,omitempty"`
 	ProfileImage string        `json:"profile_image"`
 }
 
@@ -33,6 +35,11 @@ type PostResponse struct {
 	Author PostAuthor `json:"author"`
 }
 
+// visiblePostsFilter matches published posts whose author is not banned or
+// suspended.
+func visiblePostsFilter() bson.M {
+	return bson.M{"published": true, "author_restricted": bson.M{"$ne": true}}
+}
 
 func GetHomeFeed(client *mongo.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -42,9 +49,11 @@ func GetHomeFeed(client *mongo.Client) gin.HandlerFunc {
 		postCol := database.OpenCollection("posts", client)
 		userCol := database.OpenCollection("users", client)
 
+		filter := visiblePostsFilter()
+
 		cursor, err := postCol.Find(
 			ctx,
-			bson.M{"published": true},
+			filter,
 			options.Find().
 				SetSort(bson.D{{Key: "created_at", Value: -1}}).
 				SetLimit(3),
@@ -73,6 +82,7 @@ func GetHomeFeed(client *mongo.Client) gin.HandlerFunc {
 				Author: PostAuthor{
 					ID:           user.Id,
 					Username:     user.UserName,
+					Handle:       user.Handle,
 					ProfileImage: user.ProfileImage,
 				},
 			})
@@ -90,9 +100,11 @@ func GetTrendingPosts(client *mongo.Client) gin.HandlerFunc {
 		postCol := database.OpenCollection("posts", client)
 		userCol := database.OpenCollection("users", client)
 
+		filter := visiblePostsFilter()
+
 		cursor, err := postCol.Find(
 			ctx,
-			bson.M{"published": true},
+			filter,
 			options.Find().
 				SetSort(bson.D{{Key: "view_count", Value: -1}}).
 				SetLimit(10),
@@ -125,6 +137,7 @@ func GetTrendingPosts(client *mongo.Client) gin.HandlerFunc {
 				Author: PostAuthor{
 					ID:           user.Id,
 					Username:     user.UserName,
+					Handle:       user.Handle,
 					ProfileImage: user.ProfileImage,
 				},
 			})
@@ -180,9 +193,11 @@ func GetAllPosts(client *mongo.Client) gin.HandlerFunc {
 		postCol := database.OpenCollection("posts", client)
 		userCol := database.OpenCollection("users", client)
 
+		filter := visiblePostsFilter()
+
 		cursor, err := postCol.Find(
 			ctx,
-			bson.M{"published": true},
+			filter,
 			options.Find().SetSort(bson.M{"created_at": -1}),
 		)
 		if err != nil {
@@ -209,6 +224,7 @@ func GetAllPosts(client *mongo.Client) gin.HandlerFunc {
 				Author: PostAuthor{
 					ID:           user.Id,
 					Username:     user.UserName,
+					Handle:       user.Handle,
 					ProfileImage: user.ProfileImage,
 				},
 			})
@@ -252,6 +268,7 @@ func GetPostBySlug(client *mongo.Client) gin.HandlerFunc {
 			Author: PostAuthor{
 				ID:           user.Id,
 				Username:     user.UserName,
+				Handle:       user.Handle,
 				ProfileImage: user.ProfileImage,
 			},
 		})
@@ -334,7 +351,7 @@ func DeletePost(client *mongo.Client) gin.HandlerFunc {
 		var post models.Post
 		col.FindOne(context.Background(), bson.M{"_id": postObjId}).Decode(&post)
 
-		if post.AuthorID != userObjId {
+		if post.AuthorID != userObjId && !utils.HasPermission(c.GetString("role"), utils.PermPostsDeleteAny) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed"})
 			return
 		}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// profileValidator checks profile edits against the validate tags on
+// models.User and reports fields by their JSON name.
+var profileValidator = func() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	})
+	return v
+}()
+
+func profileResponse(user models.User) gin.H {
+	return gin.H{
+		"id":            user.Id.Hex(),
+		"name":          user.UserName,
+		"handle":        user.Handle,
+		"bio":           user.Bio,
+		"profile_image": user.ProfileImage,
+		"updated_at":    user.UpdatedAt,
+	}
+}
+
+// UpdateProfile applies a partial update of the caller's name, bio and
+// profile image. Only the fields present in the body are changed, and every
+// effective change is kept in the profile_changes collection. An empty
+// profile_image goes back to the generated initials avatar, which also
+// follows a change of name. Replacing an uploaded avatar deletes its files.
+func UpdateProfile(client *mongo.Client, store utils.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req struct {
+			Name         *string `json:"name"`
+			Bio          *string `json:"bio"`
+			ProfileImage *string `json:"profile_image"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		updated := user
+		var fields []string
+		if req.Name != nil {
+			updated.UserName = strings.TrimSpace(*req.Name)
+			fields = append(fields, "UserName")
+		}
+		if req.Bio != nil {
+			updated.Bio = strings.TrimSpace(*req.Bio)
+			fields = append(fields, "Bio")
+		}
+		if req.ProfileImage != nil {
+			updated.ProfileImage = strings.TrimSpace(*req.ProfileImage)
+			fields = append(fields, "ProfileImage")
+		}
+		if len(fields) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+			return
+		}
+
+		if err := profileValidator.StructPartial(updated, fields...); err != nil {
+			var invalid validator.ValidationErrors
+			if !errors.As(err, &invalid) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+				return
+			}
+
+			details := gin.H{}
+			for _, fe := range invalid {
+				details[fe.Field()] = fe.Tag()
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"code":    "PROFILE_INVALID",
+				"details": details,
+			})
+			return
+		}
+
+		if updated.ProfileImage == "" || (isGeneratedAvatar(user.ProfileImage) && updated.ProfileImage == user.ProfileImage) {
+			updated.ProfileImage = generatedAvatarURL(updated.UserName)
+		}
+
+		changes := map[string]models.FieldChange{}
+		set := bson.M{}
+		for _, f := range []struct {
+			key      string
+			from, to string
+		}{
+			{"name", user.UserName, updated.UserName},
+			{"bio", user.Bio, updated.Bio},
+			{"profile_image", user.ProfileImage, updated.ProfileImage},
+		} {
+			if f.from != f.to {
+				changes[f.key] = models.FieldChange{From: f.from, To: f.to}
+				set[f.key] = f.to
+			}
+		}
+
+		if len(changes) == 0 {
+			c.JSON(http.StatusOK, gin.H{"user": profileResponse(user)})
+			return
+		}
+
+		now := time.Now()
+		set["updated_at"] = now
+		update := bson.M{"$set": set}
+
+		_, replacedAvatar := changes["profile_image"]
+		replacedAvatar = replacedAvatar && len(user.AvatarKeys) > 0
+		if replacedAvatar {
+			update["$unset"] = bson.M{"avatar_keys": ""}
+		}
+
+		if _, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.Id}, update); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+		updated.UpdatedAt = now
+
+		recordProfileChange(ctx, client, user.Id, changes, now)
+
+		if replacedAvatar {
+			if err := utils.DeleteBlobs(ctx, store, user.AvatarKeys); err != nil {
+				log.Println("UPDATE PROFILE: failed to delete previous avatar:", err)
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"user": profileResponse(updated)})
+	}
+}
+
+// recordProfileChange adds an entry to the user's profile history. Failing to
+// do so does not undo the edit.
+func recordProfileChange(ctx context.Context, client *mongo.Client, userID bson.ObjectID, changes map[string]models.FieldChange, at time.Time) {
+	if _, err := database.OpenCollection("profile_changes", client).InsertOne(ctx, models.ProfileChange{
+		UserID:    userID,
+		Changes:   changes,
+		CreatedAt: at,
+	}); err != nil {
+		log.Println("PROFILE: failed to record change:", err)
+	}
+}
+
+// ListProfileChanges returns the caller's profile edits, newest first.
+func ListProfileChanges(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+		if page < 1 {
+			page = 1
+		}
+		const pageSize = 50
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := database.OpenCollection("profile_changes", client).Find(
+			ctx,
+			bson.M{"user_id": userObjId},
+			options.Find().
+				SetSort(bson.D{{Key: "created_at", Value: -1}}).
+				SetSkip((page-1)*pageSize).
+				SetLimit(pageSize),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile history"})
+			return
+		}
+
+		changes := []models.ProfileChange{}
+		if err := cursor.All(ctx, &changes); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse profile history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"changes": changes, "page": page})
+	}
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Reauthenticate puts the current session into "sudo mode" after the user
+// confirmed their password or a second-factor code. Routes guarded by
+// middleware.RequireRecentAuth answer REAUTH_REQUIRED until this succeeds.
+func Reauthenticate(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Password     string `json:"password"`
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recovery_code"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+		if req.Password == "" && req.Code == "" && req.RecoveryCode == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password or code is required"})
+			return
+		}
+
+		sessionId, err := bson.ObjectIDFromHex(c.GetString("session_id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		userObjId, _ := bson.ObjectIDFromHex(c.GetString("user_id"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		attemptKey := "reauth:user:" + userObjId.Hex()
+
+		if wait, err := utils.CheckLockout(ctx, client, attemptKey); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Verification failed"})
+			return
+		} else if wait > 0 {
+			respondTooManyAttempts(c, wait)
+			return
+		}
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		var ok bool
+		switch {
+		case req.Password != "":
+			ok = user.Password != "" && bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) == nil
+		case user.TOTPEnabled:
+			ok, err = verifySecondFactor(ctx, userCollection, user, req.Code, req.RecoveryCode)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Verification failed"})
+				return
+			}
+		default:
+			// Accounts created through a provider or magic link may have
+			// neither a password nor 2FA. Those logins never elevate a session,
+			// so the user has to set a password (through the reset flow) or
+			// enable 2FA first.
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Set a password or enable two-factor authentication to continue",
+				"code":  "REAUTH_FACTOR_REQUIRED",
+			})
+			return
+		}
+
+		if !ok {
+			utils.RecordFailure(ctx, client, attemptKey, utils.LoginAccountPolicy)
+			recordAudit(c, client, models.AuditEvent{Type: utils.AuditReauthFailed, UserID: auditUser(user.Id), Email: user.Email})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password or code"})
+			return
+		}
+
+		utils.ResetAttempts(ctx, client, attemptKey)
+
+		until, err := utils.ElevateSession(ctx, client, sessionId)
+		if errors.Is(err, utils.ErrSessionRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm identity"})
+			return
+		}
+
+		recordAudit(c, client, models.AuditEvent{Type: utils.AuditReauthSucceeded, UserID: auditUser(user.Id), Email: user.Email})
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Identity confirmed",
+			"elevated_until": until,
+		})
+	}
+}
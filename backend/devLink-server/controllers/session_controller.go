@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func setAuthCookies(c *gin.Context, accessToken, refreshToken string) {
+	c.SetCookie(
+		"access_token",
+		accessToken,
+		int(utils.AccessTokenTTL.Seconds()),
+		"/",
+		"localhost",
+		false,
+		true,
+	)
+
+	// The refresh token is only ever needed by the /auth endpoints.
+	c.SetCookie(
+		"refresh_token",
+		refreshToken,
+		int(utils.RefreshTokenTTL.Seconds()),
+		"/auth",
+		"localhost",
+		false,
+		true,
+	)
+}
+
+func clearAuthCookies(c *gin.Context) {
+	c.SetCookie("access_token", "", -1, "/", "localhost", false, true)
+	c.SetCookie("refresh_token", "", -1, "/auth", "localhost", false, true)
+}
+
+// startSession creates a new session for user and sets the access and refresh
+// token cookies on the response. Only logins that checked a password or TOTP
+// code pass elevated; see middleware.RequireRecentAuth.
+func startSession(ctx context.Context, c *gin.Context, client *mongo.Client, user models.User, elevated bool) error {
+	session, refreshToken, err := utils.CreateSession(ctx, client, user.Id, c.Request.UserAgent(), c.ClientIP(), elevated)
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := utils.GenerateToken(user.Id.Hex(), user.Email, user.Role, session.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	setAuthCookies(c, accessToken, refreshToken)
+	return nil
+}
+
+func RefreshToken(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		refreshToken, err := c.Cookie("refresh_token")
+		if err != nil || refreshToken == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token missing"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		session, newRefreshToken, err := utils.RotateSession(ctx, client, refreshToken, c.ClientIP())
+		if err != nil {
+			if errors.Is(err, utils.ErrRefreshTokenReused) {
+				log.Println("REFRESH TOKEN REUSE DETECTED, session family revoked")
+				disconnectSession(session.ID.Hex())
+				recordAudit(c, client, models.AuditEvent{
+					Type:      utils.AuditRefreshReused,
+					UserID:    auditUser(session.UserID),
+					SessionID: session.ID.Hex(),
+				})
+			} else if !errors.Is(err, utils.ErrSessionNotFound) && !errors.Is(err, utils.ErrSessionRevoked) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+				return
+			}
+
+			clearAuthCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired session"})
+			return
+		}
+
+		// Re-read the user so that the new access token carries current claims.
+		var user models.User
+		err = database.OpenCollection("users", client).FindOne(ctx, bson.M{"_id": session.UserID}).Decode(&user)
+		if err != nil {
+			utils.RevokeSession(ctx, client, session.ID, "user_not_found")
+			clearAuthCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		if restricted := utils.AccountRestriction(user); restricted != nil {
+			utils.RevokeSession(ctx, client, session.ID, "restricted")
+			clearAuthCookies(c)
+			c.JSON(http.StatusForbidden, restricted.Body())
+			return
+		}
+
+		accessToken, err := utils.GenerateToken(user.Id.Hex(), user.Email, user.Role, session.ID.Hex())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		setAuthCookies(c, accessToken, newRefreshToken)
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session refreshed"})
+	}
+}
+
+// ListSessions returns the caller's live sessions, most recently used first.
+func ListSessions(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+		currentSession := c.GetString("session_id")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := database.OpenCollection("sessions", client).Find(
+			ctx,
+			bson.M{
+				"user_id":    userObjId,
+				"revoked_at": bson.M{"$exists": false},
+				"expires_at": bson.M{"$gt": time.Now()},
+			},
+			options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}}),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+			return
+		}
+
+		var sessions []models.Session
+		if err := cursor.All(ctx, &sessions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse sessions"})
+			return
+		}
+
+		response := []gin.H{}
+		for _, session := range sessions {
+			response = append(response, gin.H{
+				"id":           session.ID.Hex(),
+				"user_agent":   session.UserAgent,
+				"ip":           session.IP,
+				"created_at":   session.CreatedAt,
+				"last_seen_at": session.LastSeenAt,
+				"expires_at":   session.ExpiresAt,
+				"current":      session.ID.Hex() == currentSession,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"sessions": response})
+	}
+}
+
+// RevokeSession signs out one of the caller's devices.
+func RevokeSession(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		sessionObjId, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session id"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		result, err := database.OpenCollection("sessions", client).UpdateOne(
+			ctx,
+			bson.M{"_id": sessionObjId, "user_id": userObjId, "revoked_at": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": "signed_out_by_user"}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+
+		disconnectSession(sessionObjId.Hex())
+
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditSessionRevoked,
+			UserID:   auditUser(userObjId),
+			Metadata: bson.M{"revoked_session_id": sessionObjId.Hex()},
+		})
+
+		if sessionObjId.Hex() == c.GetString("session_id") {
+			clearAuthCookies(c)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+	}
+}
+
+// RevokeOtherSessions signs out every device of the caller except this one.
+func RevokeOtherSessions(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+		currentSession, _ := bson.ObjectIDFromHex(c.GetString("session_id"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		sessionCol := database.OpenCollection("sessions", client)
+		filter := bson.M{
+			"user_id":    userObjId,
+			"_id":        bson.M{"$ne": currentSession},
+			"revoked_at": bson.M{"$exists": false},
+		}
+
+		cursor, err := sessionCol.Find(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+		var sessions []models.Session
+		if err := cursor.All(ctx, &sessions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+
+		result, err := sessionCol.UpdateMany(ctx, filter, bson.M{
+			"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": "signed_out_by_user"},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+
+		revokedIds := []string{}
+		for _, session := range sessions {
+			disconnectSession(session.ID.Hex())
+			revokedIds = append(revokedIds, session.ID.Hex())
+		}
+
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditSessionRevoked,
+			UserID:   auditUser(userObjId),
+			Metadata: bson.M{"revoked_session_ids": revokedIds, "scope": "others"},
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Signed out of all other sessions",
+			"revoked": result.ModifiedCount,
+		})
+	}
+}
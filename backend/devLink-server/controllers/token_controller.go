@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	defaultTokenLifetimeDays = 30
+	maxTokenLifetimeDays     = 365
+	maxTokensPerUser         = 50
+)
+
+func CreateAccessToken(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req struct {
+			Name          string   `json:"name"`
+			Scopes        []string `json:"scopes"`
+			ExpiresInDays int      `json:"expires_in_days"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || len(req.Name) > 64 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Token name must be 1-64 characters"})
+			return
+		}
+
+		if len(req.Scopes) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "At least one scope is required"})
+			return
+		}
+
+		scopes := []string{}
+		seen := map[string]bool{}
+		for _, scope := range req.Scopes {
+			if _, ok := utils.TokenScopes[scope]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + scope})
+				return
+			}
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+
+		if req.ExpiresInDays == 0 {
+			req.ExpiresInDays = defaultTokenLifetimeDays
+		}
+		if req.ExpiresInDays < 1 || req.ExpiresInDays > maxTokenLifetimeDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must be between 1 and 365"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		tokenCol := database.OpenCollection("personal_access_tokens", client)
+
+		count, err := tokenCol.CountDocuments(ctx, bson.M{
+			"user_id":    userObjId,
+			"revoked_at": bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": time.Now()},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+			return
+		}
+		if count >= maxTokensPerUser {
+			c.JSON(http.StatusConflict, gin.H{"error": "Too many active tokens, revoke one first"})
+			return
+		}
+
+		token, err := utils.GeneratePAT()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+			return
+		}
+
+		pat := models.PersonalAccessToken{
+			ID:        bson.NewObjectID(),
+			UserID:    userObjId,
+			Name:      req.Name,
+			Prefix:    token[:len(utils.PATPrefix)+6],
+			TokenHash: utils.HashToken(token),
+			Scopes:    scopes,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().AddDate(0, 0, req.ExpiresInDays),
+		}
+
+		if _, err := tokenCol.InsertOne(ctx, pat); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+			return
+		}
+
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditTokenCreated,
+			UserID:   auditUser(userObjId),
+			Metadata: bson.M{"token_id": pat.ID.Hex(), "name": pat.Name, "scopes": pat.Scopes},
+		})
+
+		// The plain token is only ever shown here.
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Token created. Copy it now, it will not be shown again.",
+			"token":   token,
+			"details": pat,
+		})
+	}
+}
+
+func ListAccessTokens(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := database.OpenCollection("personal_access_tokens", client).Find(
+			ctx,
+			bson.M{"user_id": userObjId, "revoked_at": bson.M{"$exists": false}},
+			options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tokens"})
+			return
+		}
+
+		tokens := []models.PersonalAccessToken{}
+		if err := cursor.All(ctx, &tokens); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse tokens"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"tokens": tokens, "available_scopes": utils.TokenScopes})
+	}
+}
+
+func RevokeAccessToken(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		tokenObjId, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token id"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		result, err := database.OpenCollection("personal_access_tokens", client).UpdateOne(
+			ctx,
+			bson.M{"_id": tokenObjId, "user_id": userObjId, "revoked_at": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+			return
+		}
+
+		recordAudit(c, client, models.AuditEvent{
+			Type:     utils.AuditTokenRevoked,
+			UserID:   auditUser(userObjId),
+			Metadata: bson.M{"token_id": tokenObjId.Hex()},
+		})
+
+		c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+	}
+}
@@ -0,0 +1,388 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mfaChallengeTTL is how long a user has to enter their second factor after
+// the password was accepted.
+const mfaChallengeTTL = 5 * time.Minute
+
+// mfaCookie hands an MFA challenge to the login page when the first factor
+// was given elsewhere, such as at an OAuth provider, so that the token never
+// shows up in a URL. It is scoped to the 2FA endpoints.
+const mfaCookie = "mfa_token"
+
+func setMFACookie(c *gin.Context, token string, maxAge int) {
+	c.SetCookie(mfaCookie, token, maxAge, "/auth/2fa", "localhost", false, true)
+}
+
+const recoveryCodeCount = 10
+
+// verifySecondFactor checks a TOTP code or, failing that, a recovery code for
+// user. TOTP codes cannot be replayed and recovery codes are consumed.
+func verifySecondFactor(ctx context.Context, userCollection *mongo.Collection, user models.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+
+		result, err := userCollection.UpdateOne(ctx,
+			bson.M{
+				"_id": user.Id,
+				"$or": bson.A{
+					bson.M{"totp_last_step": bson.M{"$exists": false}},
+					bson.M{"totp_last_step": bson.M{"$lt": step}},
+				},
+			},
+			bson.M{"$set": bson.M{"totp_last_step": step}},
+		)
+		if err != nil {
+			return false, err
+		}
+		return result.MatchedCount == 1, nil
+	}
+
+	recoveryCode = strings.ToLower(strings.TrimSpace(recoveryCode))
+	if recoveryCode == "" {
+		return false, nil
+	}
+
+	for _, hash := range user.RecoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(recoveryCode)) != nil {
+			continue
+		}
+
+		result, err := userCollection.UpdateOne(ctx,
+			bson.M{"_id": user.Id, "recovery_codes": hash},
+			bson.M{"$pull": bson.M{"recovery_codes": hash}},
+		)
+		if err != nil {
+			return false, err
+		}
+		return result.MatchedCount == 1, nil
+	}
+
+	return false, nil
+}
+
+func SetupTwoFactor(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if user.TOTPEnabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+			return
+		}
+
+		secret, err := utils.GenerateTOTPSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+			return
+		}
+
+		_, err = userCollection.UpdateOne(ctx, bson.M{"_id": userObjId}, bson.M{
+			"$set": bson.M{"totp_pending_secret": secret},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start 2FA setup"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"secret":      secret,
+			"otpauth_uri": utils.TOTPURI(secret, user.Email),
+		})
+	}
+}
+
+func EnableTwoFactor(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req struct {
+			Code string `json:"code"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if user.TOTPEnabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+			return
+		}
+
+		if user.TOTPPendingSecret == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Start 2FA setup first"})
+			return
+		}
+
+		step, ok := utils.ValidateTOTP(user.TOTPPendingSecret, req.Code, time.Now())
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+			return
+		}
+
+		codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+			return
+		}
+
+		hashes := make([]string, 0, len(codes))
+		for _, code := range codes {
+			hash, err := HashPassword(code)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+				return
+			}
+			hashes = append(hashes, hash)
+		}
+
+		_, err = userCollection.UpdateOne(ctx,
+			bson.M{"_id": userObjId, "totp_pending_secret": user.TOTPPendingSecret},
+			bson.M{
+				"$set": bson.M{
+					"totp_enabled":   true,
+					"totp_secret":    user.TOTPPendingSecret,
+					"totp_last_step": step,
+					"recovery_codes": hashes,
+					"updated_at":     time.Now(),
+				},
+				"$unset": bson.M{"totp_pending_secret": ""},
+			},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable 2FA"})
+			return
+		}
+
+		recordAudit(c, client, models.AuditEvent{Type: utils.AuditTwoFactorEnabled, UserID: auditUser(user.Id), Email: user.Email})
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Two-factor authentication enabled",
+			"recovery_codes": codes,
+		})
+	}
+}
+
+func DisableTwoFactor(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req struct {
+			Password     string `json:"password"`
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recovery_code"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if !user.TOTPEnabled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+			return
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+			return
+		}
+
+		ok, err := verifySecondFactor(ctx, userCollection, user, req.Code, req.RecoveryCode)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+			return
+		}
+
+		_, err = userCollection.UpdateOne(ctx, bson.M{"_id": userObjId}, bson.M{
+			"$set": bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{
+				"totp_enabled":        "",
+				"totp_secret":         "",
+				"totp_pending_secret": "",
+				"totp_last_step":      "",
+				"recovery_codes":      "",
+			},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable 2FA"})
+			return
+		}
+
+		recordAudit(c, client, models.AuditEvent{Type: utils.AuditTwoFactorDisabled, UserID: auditUser(user.Id), Email: user.Email})
+
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+	}
+}
+
+// VerifyTwoFactor completes a login that LoginUser answered with an MFA
+// challenge. The challenge is read from the body or, failing that, from the
+// mfa_token cookie.
+func VerifyTwoFactor(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			MFAToken     string `json:"mfa_token"`
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recovery_code"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+		if req.MFAToken == "" {
+			req.MFAToken, _ = c.Cookie(mfaCookie)
+		}
+		if req.MFAToken == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		claims, err := utils.VerifyPurposeToken(req.MFAToken, "mfa")
+		if err != nil {
+			setMFACookie(c, "", -1)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "MFA challenge expired, please log in again"})
+			return
+		}
+
+		userObjId, err := bson.ObjectIDFromHex(claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid MFA challenge"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		attemptKey := "mfa:user:" + claims.UserID
+
+		if wait, err := utils.CheckLockout(ctx, client, attemptKey); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Verification failed"})
+			return
+		} else if wait > 0 {
+			respondTooManyAttempts(c, wait)
+			return
+		}
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		if !user.TOTPEnabled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+			return
+		}
+
+		ok, err := verifySecondFactor(ctx, userCollection, user, req.Code, req.RecoveryCode)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Verification failed"})
+			return
+		}
+		if !ok {
+			utils.RecordFailure(ctx, client, attemptKey, utils.LoginAccountPolicy)
+			recordAudit(c, client, models.AuditEvent{Type: utils.AuditLoginFailed, UserID: auditUser(user.Id), Email: user.Email, Reason: "invalid_2fa_code"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+			return
+		}
+
+		utils.ResetAttempts(ctx, client, attemptKey)
+		setMFACookie(c, "", -1)
+
+		if rejectRestricted(c, client, user) {
+			return
+		}
+
+		if err := startSession(ctx, c, client, user, true); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		recordLogin(c, client, user, "2fa")
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Login successful",
+			"user": gin.H{
+				"id":    user.Id.Hex(),
+				"name":  user.UserName,
+				"email": user.Email,
+				"role":  user.Role,
+			},
+		})
+	}
+}
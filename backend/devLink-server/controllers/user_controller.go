@@ -2,14 +2,19 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ayushmehta03/devLink-backend/database"
 	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 
@@ -32,7 +37,6 @@ func GetUserProfile(client *mongo.Client )gin.HandlerFunc{
 
 
 		userCollection:=database.OpenCollection("users",client)
-		postCollection:=database.OpenCollection("posts",client)
 
 		var user models.User
 
@@ -44,24 +48,206 @@ func GetUserProfile(client *mongo.Client )gin.HandlerFunc{
 		return 
 		}
 
-		filter:=bson.M{
-			"author_id":user.Id,
-			"published":true,
+		respondUserProfile(ctx,c,client,user)
+	}
+}
+
+// GetUserProfileByHandle serves GetUserProfile's response for /u/:handle.
+// Handles are matched case-insensitively, and a handle the user has since
+// changed redirects to the current one.
+func GetUserProfileByHandle(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handle := utils.NormalizeHandle(c.Param("handle"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		err := userCollection.FindOne(ctx, bson.M{"handle_lower": handle}).Decode(&user)
+		if err == nil {
+			respondUserProfile(ctx, c, client, user)
+			return
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
 		}
 
-		cursor,_:=postCollection.Find(ctx,filter)
+		var redirect models.HandleRedirect
+		err = database.OpenCollection("handle_redirects", client).FindOne(ctx, bson.M{
+			"_id":        handle,
+			"expires_at": bson.M{"$gt": time.Now()},
+		}).Decode(&redirect)
+		if err == nil {
+			err = userCollection.FindOne(ctx, bson.M{"_id": redirect.UserID}).Decode(&user)
+		}
+		if err != nil || user.Handle == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
 
-		 posts:=[]models.Post{}
+		// Not permanent: once the redirect expires the old handle can be
+		// claimed by someone else, and cached 301s would outlive that.
+		c.Redirect(http.StatusFound, "/u/"+user.Handle)
+	}
+}
 
-		cursor.All(ctx,&posts)
+func respondUserProfile(ctx context.Context, c *gin.Context, client *mongo.Client, user models.User) {
+	cursor, err := database.OpenCollection("posts", client).Find(ctx, bson.M{
+		"author_id": user.Id,
+		"published": true,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return
+	}
 
-c.JSON(http.StatusOK, gin.H{
-			"user": gin.H{
-				"name": user.UserName,
-				"bio":  user.Bio,
-			},
-			"posts": posts,
-		})	}
+	posts := []models.Post{}
+	cursor.All(ctx, &posts)
+
+	// Whether the viewer follows this user; false on their own profile.
+	isFollowing := false
+	if viewerId, err := bson.ObjectIDFromHex(c.GetString("user_id")); err == nil && viewerId != user.Id {
+		if isFollowing, err = utils.IsFollowing(ctx, client, viewerId, user.Id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": gin.H{
+			"id":     user.Id.Hex(),
+			"name":   user.UserName,
+			"handle": user.Handle,
+			"bio":    user.Bio,
+
+			"profile_image":   user.ProfileImage,
+			"followers_count": user.FollowersCount,
+			"following_count": user.FollowingCount,
+			"is_following":    isFollowing,
+		},
+		"posts": posts,
+	})
+}
+
+// ChangeHandle sets the caller's handle. Handles can be changed once per
+// utils.HandleChangeCooldown; the old handle keeps redirecting to the user for
+// utils.HandleRedirectTTL and cannot be claimed by others in that time.
+func ChangeHandle(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req struct {
+			Handle string `json:"handle"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+			return
+		}
+
+		handle := strings.TrimPrefix(strings.TrimSpace(req.Handle), "@")
+		if err := utils.ValidateHandle(handle); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "HANDLE_INVALID"})
+			return
+		}
+
+		userObjId, _ := bson.ObjectIDFromHex(userId.(string))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": userObjId}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if handle == user.Handle {
+			c.JSON(http.StatusOK, gin.H{"handle": user.Handle})
+			return
+		}
+
+		now := time.Now()
+		newLower := utils.NormalizeHandle(handle)
+
+		// Changing only the capitalisation is always allowed.
+		caseOnly := newLower == user.HandleLower
+
+		if !caseOnly && user.HandleChangedAt != nil {
+			if next := user.HandleChangedAt.Add(utils.HandleChangeCooldown); now.Before(next) {
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"error":    "Handle was changed recently",
+					"code":     "HANDLE_CHANGE_COOLDOWN",
+					"retry_at": next,
+				})
+				return
+			}
+		}
+
+		if !caseOnly {
+			available, err := utils.IsHandleAvailable(ctx, client, handle, user.Id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check handle"})
+				return
+			}
+			if !available {
+				c.JSON(http.StatusConflict, gin.H{"error": "Handle is already taken", "code": "HANDLE_TAKEN"})
+				return
+			}
+		}
+
+		set := bson.M{
+			"handle":       handle,
+			"handle_lower": newLower,
+			"updated_at":   now,
+		}
+		if !caseOnly {
+			set["handle_changed_at"] = now
+		}
+
+		_, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.Id}, bson.M{"$set": set})
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Handle is already taken", "code": "HANDLE_TAKEN"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change handle"})
+			return
+		}
+
+		if !caseOnly {
+			redirects := database.OpenCollection("handle_redirects", client)
+
+			// Taking back one of your own old handles drops its redirect.
+			redirects.DeleteOne(ctx, bson.M{"_id": newLower, "user_id": user.Id})
+
+			if user.HandleLower != "" {
+				_, err := redirects.UpdateOne(
+					ctx,
+					bson.M{"_id": user.HandleLower},
+					bson.M{"$set": bson.M{
+						"user_id":    user.Id,
+						"created_at": now,
+						"expires_at": now.Add(utils.HandleRedirectTTL),
+					}},
+					options.UpdateOne().SetUpsert(true),
+				)
+				if err != nil {
+					log.Println("CHANGE HANDLE: failed to store redirect:", err)
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"handle": handle})
+	}
 }
 
 func SearchUsers(client *mongo.Client)gin.HandlerFunc{
@@ -81,9 +267,9 @@ func SearchUsers(client *mongo.Client)gin.HandlerFunc{
 		userCollection:=database.OpenCollection("users",client)
 
 		filter:=bson.M{
-			"name":bson.M{
-				"$regex":query,
-				"$options":"i",
+			"$or":bson.A{
+				bson.M{"name":bson.M{"$regex":query,"$options":"i"}},
+				bson.M{"handle_lower":utils.NormalizeHandle(query)},
 			},
 		}
 
@@ -106,6 +292,7 @@ func SearchUsers(client *mongo.Client)gin.HandlerFunc{
 
 		users=append(users, gin.H{
 			"name":user.UserName,
+			"handle":user.Handle,
 			"bio":user.Bio,
 		})
 	}
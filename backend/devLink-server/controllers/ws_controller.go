@@ -4,13 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/ayushmehta03/devLink-backend/database"
 	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -18,15 +17,17 @@ import (
 
 
 
+// Browsers always send Origin on a WebSocket handshake and it cannot be
+// forged by a page, so checking it is what keeps another site from opening a
+// socket with the user's cookies. Clients without one are not browsers.
 var upgarder=websocket.Upgrader{
 	
 	CheckOrigin: func(r*http.Request) bool{
-		return true
+		origin:=r.Header.Get("Origin")
+		return origin==""||utils.IsAllowedOrigin(origin)
 	},
 }
 
-var roomClients=make(map[string]map[*websocket.Conn]bool)
-
 func ChatWebSocket(client *mongo.Client)gin.HandlerFunc{
 	return func(c *gin.Context){
 		tokenString,err:=c.Cookie("access_token")
@@ -37,22 +38,44 @@ func ChatWebSocket(client *mongo.Client)gin.HandlerFunc{
 		}
 
 
-		secret:=os.Getenv("JWT_SECRET")
+		claims,err:=utils.VerifyToken(tokenString)
 
+		if err!=nil||claims.SessionID==""{
+			fmt.Println("Invalid token")
+			c.JSON(http.StatusUnauthorized,gin.H{"error":"Invalid token"});
+			return 
+		}
 
-		token,err:=jwt.Parse(tokenString,func(token *jwt.Token)(interface{},error){
-			return []byte(secret),nil
-		})
+		revoked,err:=utils.IsTokenRevoked(context.Background(),client,claims)
+
+		if err!=nil||revoked{
+			c.JSON(http.StatusUnauthorized,gin.H{"error":"Invalid token"});
+			return 
+		}
+
+		if _,err:=utils.ValidateSession(context.Background(),client,claims.SessionID);err!=nil{
+			c.JSON(http.StatusUnauthorized,gin.H{"error":"Session has been revoked"});
+			return 
+		}
+
+		userId,err:=bson.ObjectIDFromHex(claims.UserID)
 
 		if err!=nil{
-			fmt.Println("Invalid token")
 			c.JSON(http.StatusUnauthorized,gin.H{"error":"Invalid token"});
 			return 
 		}
 
-		claims:=token.Claims.(jwt.MapClaims)
+		restricted,err:=utils.LoadAccountRestriction(context.Background(),client,userId)
 
-		userId,_:=bson.ObjectIDFromHex(claims["user_id"].(string))
+		if err!=nil{
+			c.JSON(http.StatusInternalServerError,gin.H{"error":"Failed to verify account"});
+			return 
+		}
+
+		if restricted!=nil{
+			c.JSON(http.StatusForbidden,restricted.Body());
+			return 
+		}
 
 		roomIDParam:=c.Param("room_id");
 
@@ -86,6 +109,14 @@ func ChatWebSocket(client *mongo.Client)gin.HandlerFunc{
 			return 
 		}
 
+		roomKey:=roomID.Hex()
+
+		joinRoom(roomKey,con,&wsClient{userID: userId.Hex(),sessionID: claims.SessionID})
+		defer func(){
+			leaveRoom(roomKey,con)
+			con.Close()
+		}()
+
 
 		for{
 			var msg struct{
@@ -98,27 +129,13 @@ func ChatWebSocket(client *mongo.Client)gin.HandlerFunc{
 				break
 			}
 
-			roomKey:=roomID.Hex()
-
-		if roomClients[roomKey]==nil{
-			roomClients[roomKey]=make(map[*websocket.Conn]bool)
-		}
-		roomClients[roomKey][con]=true
-
-
 			response := gin.H{
 	"room_id": roomKey,
 	"sender":  userId.Hex(),
 	"content": msg.Content,
 }
 
-for clientConn := range roomClients[roomKey] {
-	err := clientConn.WriteJSON(response)
-	if err != nil {
-		clientConn.Close()
-		delete(roomClients[roomKey], clientConn)
-	}
-}
+broadcastToRoom(roomKey,response)
 
 
 
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsClient is one open chat socket together with the session it was opened
+// with, so that it can be closed when that session is revoked.
+type wsClient struct {
+	userID    string
+	sessionID string
+
+	// writeMu serialises writes; gorilla connections allow only one
+	// concurrent writer.
+	writeMu sync.Mutex
+}
+
+var (
+	roomClientsMu sync.Mutex
+	roomClients   = make(map[string]map[*websocket.Conn]*wsClient)
+)
+
+func joinRoom(roomKey string, con *websocket.Conn, client *wsClient) {
+	roomClientsMu.Lock()
+	defer roomClientsMu.Unlock()
+
+	if roomClients[roomKey] == nil {
+		roomClients[roomKey] = make(map[*websocket.Conn]*wsClient)
+	}
+	roomClients[roomKey][con] = client
+}
+
+func leaveRoom(roomKey string, con *websocket.Conn) {
+	roomClientsMu.Lock()
+	defer roomClientsMu.Unlock()
+
+	delete(roomClients[roomKey], con)
+	if len(roomClients[roomKey]) == 0 {
+		delete(roomClients, roomKey)
+	}
+}
+
+// broadcastToRoom sends v to every socket in the room, dropping sockets that
+// fail to receive it.
+func broadcastToRoom(roomKey string, v interface{}) {
+	roomClientsMu.Lock()
+	targets := make(map[*websocket.Conn]*wsClient, len(roomClients[roomKey]))
+	for con, client := range roomClients[roomKey] {
+		targets[con] = client
+	}
+	roomClientsMu.Unlock()
+
+	for con, client := range targets {
+		client.writeMu.Lock()
+		err := con.WriteJSON(v)
+		client.writeMu.Unlock()
+
+		if err != nil {
+			con.Close()
+			leaveRoom(roomKey, con)
+		}
+	}
+}
+
+// closeSockets closes every open socket for which match returns true. The
+// reader loop of each socket then fails and unregisters it.
+func closeSockets(match func(*wsClient) bool) {
+	roomClientsMu.Lock()
+	var targets []*websocket.Conn
+	for _, conns := range roomClients {
+		for con, client := range conns {
+			if match(client) {
+				targets = append(targets, con)
+			}
+		}
+	}
+	roomClientsMu.Unlock()
+
+	for _, con := range targets {
+		con.Close()
+	}
+}
+
+// disconnectSession closes the sockets opened with the given session.
+func disconnectSession(sessionID string) {
+	closeSockets(func(client *wsClient) bool { return client.sessionID == sessionID })
+}
+
+// disconnectUser closes every socket of the given user.
+func disconnectUser(userID string) {
+	closeSockets(func(client *wsClient) bool { return client.userID == userID })
+}
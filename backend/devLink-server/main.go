@@ -8,8 +8,13 @@ import (
 
 	"github.com/gin-contrib/cors"
 
+	"github.com/ayushmehta03/devLink-backend/controllers"
 	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/middleware"
+	"github.com/ayushmehta03/devLink-backend/migrations"
 	"github.com/ayushmehta03/devLink-backend/routes"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/ayushmehta03/devLink-backend/workers"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,11 +22,12 @@ func main() {
 
 		router:=gin.Default()
 	router.Use(cors.New(cors.Config{
-     AllowOrigins:     []string{"http://localhost:3000"},
-  AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-  AllowHeaders:     []string{"Content-Type", "Authorization"},
+     AllowOrigins:     utils.AllowedOrigins(),
+  AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+  AllowHeaders:     []string{"Content-Type", "Authorization", utils.CSRFHeader},
   AllowCredentials: true,
 }))
+	router.Use(middleware.CSRFProtect())
 
 		
 
@@ -36,11 +42,47 @@ func main() {
 		}
 	}()
 
+	if err := database.EnsureIndexes(client); err != nil {
+		log.Fatalf("Failed to create MongoDB indexes: %v", err)
+	}
+
+	if err := migrations.Run(client); err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
+
+	if err := utils.InitKeyRing(client); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	go utils.RunKeyRotation(client)
+
+	controllers.EnsureBootstrapAdmins(client)
+
+	// Printing emails to the console must be asked for with
+	// MAIL_TRANSPORT=console; a broken SMTP setup should not boot quietly.
+	transport, err := utils.NewMailerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mail transport: %v", err)
+	}
+	go workers.RunEmailQueue(client, transport)
+
+	mailer := utils.NewQueueMailer(client)
+
+	store, err := utils.NewBlobStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure blob store: %v", err)
+	}
+
+	go workers.RunAccountDeletions(client, store)
+	go workers.RunDataExports(client, mailer, store)
+	go workers.RunWeeklyDigests(client, mailer)
+	go workers.RunSuspensionExpiry(client)
+
 
-	routes.AuthRoutes(router,client)
-	routes.ProtectedRoutes(router,client)
-	routes.PublicRoutes(router,client)
+	routes.AuthRoutes(router,client,mailer,store)
+	routes.ProtectedRoutes(router,client,mailer,store)
+	routes.PublicRoutes(router,client,store)
 	routes.WebSocketRoutes(router,client)
+	routes.AdminRoutes(router,client)
 
 	port:=os.Getenv("PORT");
 
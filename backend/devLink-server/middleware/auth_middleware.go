@@ -1,54 +1,135 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"net/http"
-	"os"
+	"strings"
+	"time"
 
+	"github.com/ayushmehta03/devLink-backend/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 
 
-func AuthMiddleWare() gin.HandlerFunc {
+func AuthMiddleWare(client *mongo.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-	
-		tokenString, err := c.Cookie("access_token")
-		fmt.Println(tokenString)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		tokenString := ""
+		if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+			tokenString = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		}
+
+		if utils.IsPAT(tokenString) {
+			pat, user, err := utils.AuthenticatePAT(ctx, client, tokenString)
+			if errors.Is(err, utils.ErrInvalidPAT) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+				c.Abort()
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+				c.Abort()
+				return
+			}
+
+			if restricted := utils.AccountRestriction(*user); restricted != nil {
+				c.JSON(http.StatusForbidden, restricted.Body())
+				c.Abort()
+				return
+			}
+
+			c.Set("user_id", user.Id.Hex())
+			c.Set("email", user.Email)
+			c.Set("role", user.Role)
+			c.Set("auth_method", "pat")
+			c.Set("token_id", pat.ID.Hex())
+			c.Set("scopes", pat.Scopes)
+
+			c.Next()
+			return
+		}
+
+		if tokenString == "" {
+			cookie, err := c.Cookie("access_token")
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+				c.Abort()
+				return
+			}
+			tokenString = cookie
+		}
+
+		claims, err := utils.VerifyToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		secret := os.Getenv("JWT_SECRET")
+		if claims.SessionID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Abort()
+			return
+		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
+		revoked, err := utils.IsTokenRevoked(ctx, client, claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		session, err := utils.ValidateSession(ctx, client, claims.SessionID)
+		if err != nil {
+			if errors.Is(err, utils.ErrSessionNotFound) || errors.Is(err, utils.ErrSessionRevoked) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+			}
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
+		userObjId, err := bson.ObjectIDFromHex(claims.UserID)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 			c.Abort()
 			return
 		}
 
+		restricted, err := utils.LoadAccountRestriction(ctx, client, userObjId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify account"})
+			c.Abort()
+			return
+		}
+		if restricted != nil {
+			c.JSON(http.StatusForbidden, restricted.Body())
+			c.Abort()
+			return
+		}
+
 		// 🔓 Set user data in context
-		c.Set("user_id", claims["user_id"])
-		c.Set("email", claims["email"])
-		c.Set("role", claims["role"])
+		c.Set("user_id", claims.UserID)
+		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Set("auth_method", "session")
+		if session.ElevatedUntil != nil {
+			c.Set("elevated_until", *session.ElevatedUntil)
+		}
 
 		c.Next()
 	}
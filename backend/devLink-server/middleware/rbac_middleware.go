@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission only lets through users whose role grants permission. It
+// must run after AuthMiddleWare.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !utils.HasPermission(c.GetString("role"), permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":      "Not allowed",
+				"code":       "MISSING_PERMISSION",
+				"permission": permission,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
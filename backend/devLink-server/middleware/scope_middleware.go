@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope lets a request through if it was authenticated with a session,
+// or with a personal access token that was granted scope.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") != "pat" {
+			c.Next()
+			return
+		}
+
+		for _, granted := range c.GetStringSlice("scopes") {
+			if granted == scope {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Token is missing the required scope",
+			"code":  "INSUFFICIENT_SCOPE",
+			"scope": scope,
+		})
+		c.Abort()
+	}
+}
+
+// RequireSession rejects requests authenticated with a personal access token.
+// It guards account management routes that no scope grants access to.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") == "pat" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "This endpoint cannot be used with a personal access token",
+				"code":  "SESSION_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRecentAuth guards sensitive routes with "sudo mode": a session must
+// have signed in with a password or TOTP code, or re-authenticated through
+// POST /auth/reauth, within the last utils.SudoTTL. Clients should prompt for
+// the password on REAUTH_REQUIRED and retry. Personal access tokens cannot
+// re-authenticate, so they are rejected outright: a leaked token must not be
+// enough to do what sudo mode protects.
+func RequireRecentAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") == "pat" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "This endpoint cannot be used with a personal access token",
+				"code":  "SESSION_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		if until := c.GetTime("elevated_until"); until.After(time.Now()) {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Please confirm your identity to continue",
+			"code":  "REAUTH_REQUIRED",
+		})
+		c.Abort()
+	}
+}
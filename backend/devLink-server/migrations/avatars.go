@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// localInitialsAvatars points users still on the third-party initials avatar
+// at the one this API generates. Both take the same url-escaped seed, so only
+// the prefix changes.
+func localInitialsAvatars(ctx context.Context, client *mongo.Client) error {
+	_, err := database.OpenCollection("users", client).UpdateMany(
+		ctx,
+		bson.M{"profile_image": bson.M{"$regex": "^" + regexp.QuoteMeta(utils.LegacyInitialsAvatarPrefix)}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"profile_image": bson.M{"$replaceOne": bson.M{
+				"input":       "$profile_image",
+				"find":        utils.LegacyInitialsAvatarPrefix,
+				"replacement": utils.InitialsAvatarURL(""),
+			}},
+		}}}},
+	)
+	return err
+}
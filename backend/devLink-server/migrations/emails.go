@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// fillEmailLower stores the case-folded email of users created before it was
+// kept. It is computed here rather than with $toLower, which only folds ASCII.
+func fillEmailLower(ctx context.Context, client *mongo.Client) error {
+	col := database.OpenCollection("users", client)
+
+	cursor, err := col.Find(ctx, bson.M{"email_lower": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		if user.Email == "" {
+			continue
+		}
+
+		if _, err := col.UpdateOne(ctx, bson.M{"_id": user.Id}, bson.M{
+			"$set": bson.M{"email_lower": utils.NormalizeEmail(user.Email)},
+		}); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
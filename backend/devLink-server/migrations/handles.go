@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// assignHandles gives every user created before handles existed one derived
+// from their name, or their email if the name yields nothing usable.
+func assignHandles(ctx context.Context, client *mongo.Client) error {
+	col := database.OpenCollection("users", client)
+
+	cursor, err := col.Find(ctx, bson.M{"handle_lower": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+
+		seed := user.UserName
+		if seed == "" {
+			seed = user.Email
+		}
+
+		// Another instance or a concurrent sign-up may grab the suggested
+		// handle first; the unique index rejects that and we try again.
+		for attempt := 0; ; attempt++ {
+			handle, err := utils.SuggestHandle(ctx, client, seed, user.Id)
+			if err != nil {
+				return err
+			}
+
+			_, err = col.UpdateOne(
+				ctx,
+				bson.M{"_id": user.Id, "handle_lower": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{
+					"handle":       handle,
+					"handle_lower": utils.NormalizeHandle(handle),
+					"updated_at":   time.Now(),
+				}},
+			)
+			if err == nil {
+				break
+			}
+			if !mongo.IsDuplicateKeyError(err) || attempt >= 5 {
+				return err
+			}
+		}
+	}
+
+	return cursor.Err()
+}
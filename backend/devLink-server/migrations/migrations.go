@@ -0,0 +1,61 @@
+// Package migrations applies one-off data migrations at start-up. Each
+// migration runs once per database; applied ones are recorded in the
+// schema_migrations collection. Migrations must be idempotent, since two
+// instances starting together may both run one.
+package migrations
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type migration struct {
+	id  string
+	run func(ctx context.Context, client *mongo.Client) error
+}
+
+// migrations are applied in order. Never reorder or rename an entry.
+var migrations = []migration{
+	{"0001_assign_handles", assignHandles},
+	{"0002_local_initials_avatars", localInitialsAvatars},
+	{"0003_email_lower", fillEmailLower},
+	{"0004_author_restricted", flagRestrictedAuthors},
+}
+
+// Run applies every migration that has not been applied yet.
+func Run(client *mongo.Client) error {
+	col := database.OpenCollection("schema_migrations", client)
+
+	for _, m := range migrations {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+
+		count, err := col.CountDocuments(ctx, bson.M{"_id": m.id})
+		if err != nil {
+			cancel()
+			return err
+		}
+		if count > 0 {
+			cancel()
+			continue
+		}
+
+		log.Println("Applying migration", m.id)
+		if err := m.run(ctx, client); err != nil {
+			cancel()
+			return err
+		}
+
+		_, err = col.InsertOne(ctx, bson.M{"_id": m.id, "applied_at": time.Now()})
+		cancel()
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// flagRestrictedAuthors marks the posts of users who were banned or suspended
+// before posts carried author_restricted. Suspensions that have already ended
+// are left to workers.RunSuspensionExpiry.
+func flagRestrictedAuthors(ctx context.Context, client *mongo.Client) error {
+	ids, err := utils.RestrictedUserIDs(ctx, client)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := utils.SetAuthorRestricted(ctx, client, id, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	DeletionPending   = "pending"
+	DeletionRunning   = "running"
+	DeletionCancelled = "cancelled"
+	DeletionCompleted = "completed"
+)
+
+// AccountDeletion is both the work item for deleting an account and the audit
+// record of it; it is kept after the user document is gone. Active is set
+// while the deletion is pending or running so that a user has at most one.
+type AccountDeletion struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	EmailHash string        `bson:"email_hash" json:"-"`
+
+	Status string `bson:"status" json:"status"`
+	Active bool   `bson:"active,omitempty" json:"-"`
+
+	RequestedAt  time.Time  `bson:"requested_at" json:"requested_at"`
+	ScheduledFor time.Time  `bson:"scheduled_for" json:"scheduled_for"`
+	CancelledAt  *time.Time `bson:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+	StartedAt    *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt  *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+
+	// LeaseUntil is set by the worker processing the deletion. A running
+	// deletion whose lease has expired is picked up again.
+	LeaseUntil *time.Time     `bson:"lease_until,omitempty" json:"-"`
+	Attempts   int            `bson:"attempts" json:"attempts"`
+	LastError  string         `bson:"last_error,omitempty" json:"-"`
+	Steps      []DeletionStep `bson:"steps" json:"steps"`
+}
+
+// DeletionStep records one finished cleanup step of an AccountDeletion.
+type DeletionStep struct {
+	Name        string    `bson:"name" json:"name"`
+	Affected    int64     `bson:"affected" json:"affected"`
+	CompletedAt time.Time `bson:"completed_at" json:"completed_at"`
+}
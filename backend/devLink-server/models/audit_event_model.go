@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// AuditEvent is one security-relevant event, such as a login or a password
+// change. Events are only ever inserted, never updated, except that deleting
+// an account blanks the personal details of its events.
+type AuditEvent struct {
+	ID   bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Type string        `bson:"type" json:"type"`
+
+	// UserID is the account the event is about. It is nil for failures
+	// against an email address that has no account.
+	UserID *bson.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	// ActorID is set when someone other than the user caused the event,
+	// e.g. an admin changing their role.
+	ActorID   *bson.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	Email     string         `bson:"email,omitempty" json:"email,omitempty"`
+	SessionID string         `bson:"session_id,omitempty" json:"session_id,omitempty"`
+
+	IP        string `bson:"ip" json:"ip"`
+	UserAgent string `bson:"user_agent" json:"user_agent"`
+
+	Reason   string `bson:"reason,omitempty" json:"reason,omitempty"`
+	Metadata bson.M `bson:"metadata,omitempty" json:"metadata,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+	ExportExpired = "expired"
+)
+
+// DataExport is a user's request for an archive of their data. Active is set
+// while the export is pending or running so that a user has at most one.
+type DataExport struct {
+	ID     bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID bson.ObjectID `bson:"user_id" json:"user_id"`
+
+	Status string `bson:"status" json:"status"`
+	Active bool   `bson:"active,omitempty" json:"-"`
+
+	RequestedAt time.Time  `bson:"requested_at" json:"requested_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+
+	BlobKey           string `bson:"blob_key,omitempty" json:"-"`
+	SizeBytes         int64  `bson:"size_bytes,omitempty" json:"size_bytes,omitempty"`
+	DownloadTokenHash string `bson:"download_token_hash,omitempty" json:"-"`
+
+	LeaseUntil *time.Time `bson:"lease_until,omitempty" json:"-"`
+	Attempts   int        `bson:"attempts" json:"-"`
+	LastError  string     `bson:"last_error,omitempty" json:"-"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Follow is one edge of the follow graph: FollowerID follows FolloweeID. The
+// pair is unique.
+type Follow struct {
+	ID         bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	FollowerID bson.ObjectID `bson:"follower_id" json:"follower_id"`
+	FolloweeID bson.ObjectID `bson:"followee_id" json:"followee_id"`
+	CreatedAt  time.Time     `bson:"created_at" json:"created_at"`
+}
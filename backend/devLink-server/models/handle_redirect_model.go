@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// HandleRedirect keeps a user's previous handle pointing at them after a
+// change. The _id is the case-folded old handle.
+type HandleRedirect struct {
+	Handle    string        `bson:"_id" json:"handle"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time     `bson:"expires_at" json:"expires_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Invite lets up to MaxUses people register while registration is closed.
+// Only the SHA-256 digest of the code is stored; Prefix identifies it in
+// listings.
+type Invite struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	CreatedBy bson.ObjectID `bson:"created_by" json:"created_by"`
+
+	Note     string `bson:"note,omitempty" json:"note,omitempty"`
+	Prefix   string `bson:"prefix" json:"prefix"`
+	CodeHash string `bson:"code_hash" json:"-"`
+
+	MaxUses     int                `bson:"max_uses" json:"max_uses"`
+	Uses        int                `bson:"uses" json:"uses"`
+	Redemptions []InviteRedemption `bson:"redemptions" json:"redemptions"`
+
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time  `bson:"expires_at" json:"expires_at"`
+	RevokedAt *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// InviteRedemption records one registration made with an invite.
+type InviteRedemption struct {
+	UserID     bson.ObjectID `bson:"user_id" json:"user_id"`
+	Email      string        `bson:"email" json:"email"`
+	RedeemedAt time.Time     `bson:"redeemed_at" json:"redeemed_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MagicLink records an emailed login link. The link itself is a signed token
+// whose jti is TokenID; the record makes it single-use and binds it to the
+// browser that asked for it through NonceHash.
+type MagicLink struct {
+	ID     bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID bson.ObjectID `bson:"user_id" json:"user_id"`
+
+	TokenID   string `bson:"token_id" json:"-"`
+	NonceHash string `bson:"nonce_hash" json:"-"`
+
+	RequestIP string     `bson:"request_ip" json:"request_ip"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time  `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	EmailQueued  = "queued"
+	EmailSending = "sending"
+	EmailSent    = "sent"
+	EmailDead    = "dead"
+)
+
+// OutboundEmail is a message in the outgoing mail queue. The worker retries
+// failed deliveries with exponential backoff and moves a message to
+// EmailDead once it gives up. IdempotencyKey, when set, is unique so that
+// enqueueing the same logical message twice sends it once.
+type OutboundEmail struct {
+	ID             bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	IdempotencyKey string        `bson:"idempotency_key,omitempty" json:"idempotency_key,omitempty"`
+
+	To       string `bson:"to" json:"to"`
+	Subject  string `bson:"subject" json:"subject"`
+	Template string `bson:"template,omitempty" json:"template,omitempty"`
+	Text     string `bson:"text,omitempty" json:"-"`
+	HTML     string `bson:"html,omitempty" json:"-"`
+
+	Status        string     `bson:"status" json:"status"`
+	Attempts      int        `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time  `bson:"next_attempt_at" json:"next_attempt_at"`
+	LeaseUntil    *time.Time `bson:"lease_until,omitempty" json:"-"`
+	LastError     string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	SentAt    *time.Time `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+	DeadAt    *time.Time `bson:"dead_at,omitempty" json:"dead_at,omitempty"`
+
+	// ExpiresAt is set once the message is sent or dead, after which the
+	// record is removed by a TTL index.
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
@@ -28,6 +28,11 @@ type Post struct {
 	Published bool  `bson:"published" json:"published"`
 	ViewCount int64 `bson:"view_count" json:"view_count"`
 
+	// AuthorRestricted mirrors whether the author is banned or suspended, so
+	// that feeds can hide their posts without looking users up; see
+	// utils.SetAuthorRestricted.
+	AuthorRestricted bool `bson:"author_restricted,omitempty" json:"-"`
+
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 
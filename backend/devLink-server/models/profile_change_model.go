@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ProfileChange records one edit of a user's public profile. Changes is keyed
+// by the JSON name of the field, e.g. "bio".
+type ProfileChange struct {
+	ID        bson.ObjectID          `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    bson.ObjectID          `bson:"user_id" json:"user_id"`
+	Changes   map[string]FieldChange `bson:"changes" json:"changes"`
+	CreatedAt time.Time              `bson:"created_at" json:"created_at"`
+}
+
+type FieldChange struct {
+	From string `bson:"from" json:"from"`
+	To   string `bson:"to" json:"to"`
+}
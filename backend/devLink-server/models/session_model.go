@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Session is one login of a user, i.e. one refresh token family. Every refresh
+// rotates RefreshHash and remembers the old digest in PreviousHashes so that a
+// replayed refresh token can be detected and the whole family revoked.
+type Session struct {
+	ID     bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID bson.ObjectID `bson:"user_id" json:"user_id"`
+
+	RefreshHash    string   `bson:"refresh_hash" json:"-"`
+	PreviousHashes []string `bson:"previous_hashes" json:"-"`
+
+	UserAgent string `bson:"user_agent" json:"user_agent"`
+	IP        string `bson:"ip" json:"ip"`
+
+	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+	LastSeenAt    time.Time  `bson:"last_seen_at" json:"last_seen_at"`
+	LastRefreshAt time.Time  `bson:"last_refresh_at" json:"last_refresh_at"`
+	ExpiresAt     time.Time  `bson:"expires_at" json:"expires_at"`
+	RevokedAt     *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokedReason string     `bson:"revoked_reason,omitempty" json:"revoked_reason,omitempty"`
+
+	// ElevatedUntil is when the session's "sudo mode" runs out; see
+	// utils.ElevateSession.
+	ElevatedUntil *time.Time `bson:"elevated_until,omitempty" json:"elevated_until,omitempty"`
+}
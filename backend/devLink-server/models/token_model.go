@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PersonalAccessToken lets scripts and bots call the API as a user with a
+// limited set of scopes. Only the SHA-256 digest of the token is stored.
+type PersonalAccessToken struct {
+	ID     bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID bson.ObjectID `bson:"user_id" json:"user_id"`
+
+	Name      string   `bson:"name" json:"name"`
+	Prefix    string   `bson:"prefix" json:"prefix"`
+	TokenHash string   `bson:"token_hash" json:"-"`
+	Scopes    []string `bson:"scopes" json:"scopes"`
+
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt  time.Time  `bson:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
@@ -11,22 +11,92 @@ type User struct {
 	UserId string        `bson:"user_id" json:"user_id"`
 
 	UserName string `bson:"name" json:"name" validate:"required,min=5,max=22"`
+
+	// Handle is the user's unique @name. HandleLower is its case-folded form,
+	// which carries the unique index.
+	Handle          string     `bson:"handle,omitempty" json:"handle,omitempty"`
+	HandleLower     string     `bson:"handle_lower,omitempty" json:"-"`
+	HandleChangedAt *time.Time `bson:"handle_changed_at,omitempty" json:"-"`
 	Email    string `bson:"email" json:"email" validate:"required,email"`
+	// EmailLower is Email as compared by utils.NormalizeEmail, so that
+	// lookups can ignore case and still use an index.
+	EmailLower string `bson:"email_lower,omitempty" json:"-"`
 	Password string `bson:"password" json:"password" validate:"required,min=6"`
 
-	Bio  string `bson:"bio,omitempty" json:"bio"`
+	Bio  string `bson:"bio,omitempty" json:"bio" validate:"max=300"`
 	Role string `bson:"role" json:"role"`
-    ProfileImage  string `bson:"profile_image" json:"profile_image"`
 
+	// Locale selects the language of emails sent to the user.
+	Locale       string     `bson:"locale,omitempty" json:"locale,omitempty"`
+	DigestSentAt *time.Time `bson:"digest_sent_at,omitempty" json:"-"`
+    ProfileImage  string `bson:"profile_image" json:"profile_image" validate:"omitempty,http_url,max=2048"`
+	// AvatarKeys are the blob store keys of an uploaded avatar, one per
+	// utils.AvatarSizes entry.
+	AvatarKeys []string `bson:"avatar_keys,omitempty" json:"-"`
+
+
+	IsVerified  bool      `bson:"is_verified" json:"is_verified"`
+	OTPHash     string    `bson:"otp_hash,omitempty" json:"-"`
+	OTPExpiry   time.Time `bson:"otp_expiry,omitempty" json:"-"`
+	OTPAttempts int       `bson:"otp_attempts,omitempty" json:"-"`
+
+	ResetOTPHash     string    `bson:"reset_otp_hash,omitempty" json:"-"`
+	ResetOTPExpiry   time.Time `bson:"reset_otp_expiry,omitempty" json:"-"`
+	ResetOTPAttempts int       `bson:"reset_otp_attempts,omitempty" json:"-"`
+
+	TOTPEnabled       bool     `bson:"totp_enabled,omitempty" json:"-"`
+	TOTPSecret        string   `bson:"totp_secret,omitempty" json:"-"`
+	TOTPPendingSecret string   `bson:"totp_pending_secret,omitempty" json:"-"`
+	TOTPLastStep      int64    `bson:"totp_last_step,omitempty" json:"-"`
+	RecoveryCodes     []string `bson:"recovery_codes,omitempty" json:"-"`
+
+	Identities []ExternalIdentity `bson:"identities,omitempty" json:"-"`
+
+	// InviteCode is only read from the registration request. InviteID is the
+	// invite it redeemed.
+	InviteCode string         `bson:"-" json:"invite_code,omitempty"`
+	InviteID   *bson.ObjectID `bson:"invite_id,omitempty" json:"-"`
 
-	IsVerified bool      `bson:"is_verified" json:"is_verified"`
-	OTPHash    string    `bson:"otp_hash,omitempty" json:"-"`
-	OTPExpiry  time.Time `bson:"otp_expiry,omitempty" json:"-"`
+	// FollowersCount and FollowingCount mirror the follows collection; see
+	// utils.Follow. They are never bound from a request body, and responses
+	// that show them list them explicitly.
+	FollowersCount int64 `bson:"followers_count,omitempty" json:"-"`
+	FollowingCount int64 `bson:"following_count,omitempty" json:"-"`
+
+	// Suspension and Ban lock the user out; see utils.AccountRestriction.
+	Suspension *Suspension `bson:"suspension,omitempty" json:"-"`
+	Ban        *Ban        `bson:"ban,omitempty" json:"-"`
 
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
 
+// ExternalIdentity links a user to an account at an OAuth provider. Key is
+// "<provider>:<subject>" and is unique across users.
+type ExternalIdentity struct {
+	Key      string    `bson:"key" json:"-"`
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"subject"`
+	Username string    `bson:"username,omitempty" json:"username,omitempty"`
+	LinkedAt time.Time `bson:"linked_at" json:"linked_at"`
+}
+
+// Suspension locks a user out until Until, or until it is lifted when Until
+// is nil.
+type Suspension struct {
+	Reason string        `bson:"reason" json:"reason"`
+	Until  *time.Time    `bson:"until,omitempty" json:"until,omitempty"`
+	By     bson.ObjectID `bson:"by" json:"by"`
+	At     time.Time     `bson:"at" json:"at"`
+}
+
+// Ban locks a user out permanently.
+type Ban struct {
+	Reason string        `bson:"reason" json:"reason"`
+	By     bson.ObjectID `bson:"by" json:"by"`
+	At     time.Time     `bson:"at" json:"at"`
+}
+
 type UserLogin struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/ayushmehta03/devLink-backend/controllers"
+	"github.com/ayushmehta03/devLink-backend/middleware"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func AdminRoutes(router *gin.Engine, client *mongo.Client) {
+	admin := router.Group("/admin")
+
+	admin.Use(middleware.AuthMiddleWare(client), middleware.RequireSession())
+
+	admin.GET("/users", middleware.RequirePermission(utils.PermUsersRead), controllers.ListUsers(client))
+	admin.PUT("/users/:id/role", middleware.RequirePermission(utils.PermRolesManage), middleware.RequireRecentAuth(), controllers.UpdateUserRole(client))
+	admin.POST("/users/:id/suspension", middleware.RequirePermission(utils.PermUsersSuspend), controllers.SuspendUser(client))
+	admin.DELETE("/users/:id/suspension", middleware.RequirePermission(utils.PermUsersSuspend), controllers.UnsuspendUser(client))
+	admin.POST("/users/:id/ban", middleware.RequirePermission(utils.PermUsersBan), middleware.RequireRecentAuth(), controllers.BanUser(client))
+	admin.DELETE("/users/:id/ban", middleware.RequirePermission(utils.PermUsersBan), controllers.UnbanUser(client))
+	admin.GET("/emails", middleware.RequirePermission(utils.PermEmailsPreview), controllers.ListEmailTemplates())
+	admin.GET("/emails/:name/preview", middleware.RequirePermission(utils.PermEmailsPreview), controllers.PreviewEmailTemplate())
+	admin.GET("/emails/outbox", middleware.RequirePermission(utils.PermEmailsManage), controllers.ListOutboundEmails(client))
+	admin.GET("/emails/outbox/:id", middleware.RequirePermission(utils.PermEmailsManage), controllers.GetOutboundEmail(client))
+	admin.POST("/emails/outbox/:id/retry", middleware.RequirePermission(utils.PermEmailsManage), controllers.RetryOutboundEmail(client))
+	admin.GET("/invites", middleware.RequirePermission(utils.PermInvitesManage), controllers.ListAllInvites(client))
+	admin.GET("/audit-events", middleware.RequirePermission(utils.PermAuditRead), controllers.ListAuditEvents(client))
+}
@@ -2,20 +2,31 @@ package routes
 
 import (
 	"github.com/ayushmehta03/devLink-backend/controllers"
+	"github.com/ayushmehta03/devLink-backend/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 
 
-func AuthRoutes(router *gin.Engine,client *mongo.Client){
+func AuthRoutes(router *gin.Engine,client *mongo.Client,mailer utils.Mailer,store utils.BlobStore){
 
 	auth:=router.Group("/auth")
 
-	auth.POST("/register",controllers.RegisterUser(client));
+	auth.GET("/csrf",controllers.GetCSRFToken());
+	auth.GET("/registration",controllers.GetRegistrationInfo());
+	auth.POST("/register",controllers.RegisterUser(client,mailer));
 	auth.POST("/verify-otp",controllers.VerifyOtp(client));
-	auth.POST("resend-otp",controllers.ResendOtp(client));
+	auth.POST("resend-otp",controllers.ResendOtp(client,mailer));
 	auth.POST("/login",controllers.LoginUser(client));
 	auth.GET("/me",controllers.GetMe(client));
-	auth.POST("/logout",controllers.LogoutUser())
+	auth.POST("/forgot-password",controllers.ForgotPassword(client,mailer))
+	auth.POST("/reset-password",controllers.ResetPassword(client))
+	auth.POST("/2fa/verify",controllers.VerifyTwoFactor(client))
+	auth.POST("/magic-link",controllers.RequestMagicLink(client,mailer))
+	auth.POST("/magic-link/consume",controllers.ConsumeMagicLink(client))
+	auth.GET("/oauth/:provider/login",controllers.OAuthLogin())
+	auth.GET("/oauth/:provider/callback",controllers.OAuthCallback(client,store))
+	auth.POST("/refresh",controllers.RefreshToken(client))
+	auth.POST("/logout",controllers.LogoutUser(client))
 }
\ No newline at end of file
@@ -3,31 +3,73 @@ package routes
 import (
 	"github.com/ayushmehta03/devLink-backend/controllers"
 	"github.com/ayushmehta03/devLink-backend/middleware"
+	"github.com/ayushmehta03/devLink-backend/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 
-func ProtectedRoutes(router *gin.Engine,client *mongo.Client){
+func ProtectedRoutes(router *gin.Engine,client *mongo.Client,mailer utils.Mailer,store utils.BlobStore){
 	protected:=router.Group("/")
 
-	protected.Use(middleware.AuthMiddleWare())
-
-	protected.GET("/posts", controllers.GetAllPosts(client))
-     protected.GET("/posts/:slug", controllers.GetPostBySlug(client))
-	 protected.GET("/users/:id",controllers.GetUserProfile(client))
-	 protected.GET("/search/users",controllers.SearchUsers(client))
-	 protected.GET("/posts/tags",controllers.SearchPost(client))
-	 protected.GET("/posts/trending", controllers.GetTrendingPosts(client))
-	
-
-	protected.POST("/createpost",controllers.CreatePost(client))
-	protected.PUT("/updatepost/:id", controllers.UpdatePost(client))
-	protected.DELETE("/deletepost/:id",controllers.DeletePost(client))
-	protected.GET("/posts/archive",controllers.GetArchivePosts(client))
-	protected.POST("/chat/request",controllers.SendChatRequest(client))
-	protected.GET("/chat/requests",controllers.ReceiveChatRequest(client))
-	protected.POST("/chat/request/:id/respond",controllers.RespondChatRequest(client))
-	protected.GET("/chat/rooms/:room_id/messages",controllers.ChatHistory(client))
-	protected.POST("/chat/rooms/:room_id/seen",controllers.MarkSeenMsg(client))
-}
\ No newline at end of file
+	protected.Use(middleware.AuthMiddleWare(client))
+
+	postsRead:=protected.Group("/",middleware.RequireScope("posts:read"))
+	postsRead.GET("/posts", controllers.GetAllPosts(client))
+	postsRead.GET("/posts/:slug", controllers.GetPostBySlug(client))
+	postsRead.GET("/posts/tags",controllers.SearchPost(client))
+	postsRead.GET("/posts/trending", controllers.GetTrendingPosts(client))
+	postsRead.GET("/posts/archive",controllers.GetArchivePosts(client))
+
+	usersRead:=protected.Group("/",middleware.RequireScope("users:read"))
+	usersRead.GET("/users/:id",controllers.GetUserProfile(client))
+	usersRead.GET("/search/users",controllers.SearchUsers(client))
+	usersRead.GET("/u/:handle",controllers.GetUserProfileByHandle(client))
+	usersRead.GET("/users/:id/followers",controllers.ListFollowers(client))
+	usersRead.GET("/users/:id/following",controllers.ListFollowing(client))
+
+	followsWrite:=protected.Group("/",middleware.RequireScope("follows:write"))
+	followsWrite.POST("/users/:id/follow",controllers.FollowUser(client))
+	followsWrite.DELETE("/users/:id/follow",controllers.UnfollowUser(client))
+
+	postsWrite:=protected.Group("/",middleware.RequireScope("posts:write"))
+	postsWrite.POST("/createpost",controllers.CreatePost(client))
+	postsWrite.PUT("/updatepost/:id", controllers.UpdatePost(client))
+	postsWrite.DELETE("/deletepost/:id",middleware.RequireRecentAuth(),controllers.DeletePost(client))
+
+	chatRead:=protected.Group("/",middleware.RequireScope("chat:read"))
+	chatRead.GET("/chat/requests",controllers.ReceiveChatRequest(client))
+	chatRead.GET("/chat/rooms/:room_id/messages",controllers.ChatHistory(client))
+
+	chatWrite:=protected.Group("/",middleware.RequireScope("chat:write"))
+	chatWrite.POST("/chat/request",controllers.SendChatRequest(client,mailer))
+	chatWrite.POST("/chat/request/:id/respond",controllers.RespondChatRequest(client))
+	chatWrite.POST("/chat/rooms/:room_id/seen",controllers.MarkSeenMsg(client))
+
+	account:=protected.Group("/",middleware.RequireSession())
+	account.POST("/auth/reauth",controllers.Reauthenticate(client))
+	account.PATCH("/users/me",controllers.UpdateProfile(client,store))
+	account.PUT("/users/me/avatar",controllers.UploadAvatar(client,store))
+	account.DELETE("/users/me/avatar",controllers.RemoveAvatar(client,store))
+	account.GET("/users/me/profile-history",controllers.ListProfileChanges(client))
+	account.PUT("/users/me/password",controllers.ChangePassword(client))
+	account.PUT("/users/me/handle",controllers.ChangeHandle(client))
+	account.POST("/auth/2fa/setup",controllers.SetupTwoFactor(client))
+	account.POST("/auth/2fa/enable",controllers.EnableTwoFactor(client))
+	account.POST("/auth/2fa/disable",controllers.DisableTwoFactor(client))
+	account.GET("/tokens",controllers.ListAccessTokens(client))
+	account.POST("/tokens",middleware.RequireRecentAuth(),controllers.CreateAccessToken(client))
+	account.DELETE("/tokens/:id",controllers.RevokeAccessToken(client))
+	account.DELETE("/users/me",middleware.RequireRecentAuth(),controllers.RequestAccountDeletion(client))
+	account.GET("/users/me/deletion",controllers.GetAccountDeletion(client))
+	account.POST("/users/me/deletion/cancel",controllers.CancelAccountDeletion(client))
+	account.POST("/users/me/export",middleware.RequireRecentAuth(),controllers.RequestDataExport(client))
+	account.GET("/users/me/exports",controllers.ListDataExports(client))
+	account.GET("/auth/sessions",controllers.ListSessions(client))
+	account.DELETE("/auth/sessions",controllers.RevokeOtherSessions(client))
+	account.DELETE("/auth/sessions/:id",controllers.RevokeSession(client))
+	account.GET("/users/me/security-events",controllers.ListMyAuditEvents(client))
+	account.GET("/invites",controllers.ListInvites(client))
+	account.POST("/invites",controllers.CreateInvite(client))
+	account.DELETE("/invites/:id",controllers.RevokeInvite(client))
+}
@@ -2,14 +2,19 @@ package routes
 
 import (
 	"github.com/ayushmehta03/devLink-backend/controllers"
+	"github.com/ayushmehta03/devLink-backend/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 
-func PublicRoutes(router *gin.Engine,client *mongo.Client){
+func PublicRoutes(router *gin.Engine,client *mongo.Client,store utils.BlobStore){
 
 
 	router.GET("/home",controllers.GetHomeFeed(client));
+	router.GET("/.well-known/jwks.json",controllers.GetJWKS())
+	router.GET("/exports/:id/download",controllers.DownloadDataExport(client,store))
+	router.GET("/avatars/initials.svg",controllers.GetInitialsAvatar())
+	router.GET("/media/*key",controllers.ServeMedia(store))
 
 }
\ No newline at end of file
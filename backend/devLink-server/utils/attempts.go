@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AttemptPolicy describes when a key (an account or an IP) gets locked after
+// failed attempts. Once Threshold failures have been recorded, every further
+// failure locks the key for BaseLockout doubled per extra failure, capped at
+// MaxLockout. Counters are forgotten after Window without failures.
+type AttemptPolicy struct {
+	Threshold   int
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+	Window      time.Duration
+}
+
+var (
+	// LoginAccountPolicy throttles password guessing against one account.
+	LoginAccountPolicy = AttemptPolicy{Threshold: 5, BaseLockout: 30 * time.Second, MaxLockout: 15 * time.Minute, Window: time.Hour}
+	// LoginIPPolicy throttles one client spraying many accounts.
+	LoginIPPolicy = AttemptPolicy{Threshold: 20, BaseLockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+	// OTPIPPolicy throttles one client guessing OTPs.
+	OTPIPPolicy = AttemptPolicy{Threshold: 10, BaseLockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+	// OTPAccountPolicy throttles OTP guessing against one account. Unlike
+	// MaxOTPAttempts it survives requesting a new code.
+	OTPAccountPolicy = AttemptPolicy{Threshold: 10, BaseLockout: 5 * time.Minute, MaxLockout: 24 * time.Hour, Window: 24 * time.Hour}
+	// OTPSendPolicy throttles how many OTPs one account can have emailed;
+	// every request counts, not only failures.
+	OTPSendPolicy = AttemptPolicy{Threshold: 3, BaseLockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+	// MagicLinkRequestPolicy throttles how many login links one account or IP
+	// can have emailed; every request counts, not only failures.
+	MagicLinkRequestPolicy = AttemptPolicy{Threshold: 5, BaseLockout: 5 * time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+)
+
+// MaxOTPAttempts is how many wrong guesses an OTP survives before it is
+// invalidated and a new one has to be requested.
+const MaxOTPAttempts = 5
+
+type attemptRecord struct {
+	Key         string    `bson:"_id"`
+	Failures    int       `bson:"failures"`
+	LockedUntil time.Time `bson:"locked_until,omitempty"`
+}
+
+// CheckLockout returns how long the caller still has to wait before any of
+// keys may be tried again, or zero if none of them is locked.
+func CheckLockout(ctx context.Context, client *mongo.Client, keys ...string) (time.Duration, error) {
+	cursor, err := database.OpenCollection("login_attempts", client).Find(ctx, bson.M{
+		"_id":          bson.M{"$in": keys},
+		"locked_until": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var records []attemptRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return 0, err
+	}
+
+	var wait time.Duration
+	for _, record := range records {
+		if d := time.Until(record.LockedUntil); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// RecordFailure counts a failed attempt against key and returns the lockout it
+// triggered, if any.
+func RecordFailure(ctx context.Context, client *mongo.Client, key string, policy AttemptPolicy) (time.Duration, error) {
+	col := database.OpenCollection("login_attempts", client)
+	now := time.Now()
+
+	var record attemptRecord
+	err := col.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": key},
+		bson.M{
+			"$inc": bson.M{"failures": 1},
+			"$set": bson.M{
+				"last_failure_at": now,
+				"expires_at":      now.Add(policy.Window),
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&record)
+	if err != nil {
+		return 0, err
+	}
+
+	lockout := policy.lockoutFor(record.Failures)
+	if lockout == 0 {
+		return 0, nil
+	}
+
+	_, err = col.UpdateOne(ctx, bson.M{"_id": key}, bson.M{
+		"$set": bson.M{
+			"locked_until": now.Add(lockout),
+			"expires_at":   now.Add(lockout + policy.Window),
+		},
+	})
+	return lockout, err
+}
+
+// ResetAttempts forgets the failure counters of keys, e.g. after a successful
+// login.
+func ResetAttempts(ctx context.Context, client *mongo.Client, keys ...string) error {
+	_, err := database.OpenCollection("login_attempts", client).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": keys}})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	return err
+}
+
+func (p AttemptPolicy) lockoutFor(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+
+	exp := failures - p.Threshold
+	if exp > 30 {
+		return p.MaxLockout
+	}
+
+	lockout := time.Duration(float64(p.BaseLockout) * math.Pow(2, float64(exp)))
+	if lockout > p.MaxLockout {
+		return p.MaxLockout
+	}
+	return lockout
+}
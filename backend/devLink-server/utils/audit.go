@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Audit event types.
+const (
+	AuditRegister          = "register"
+	AuditOTPVerified       = "otp.verify.success"
+	AuditOTPFailed         = "otp.verify.failure"
+	AuditLoginSucceeded    = "login.success"
+	AuditLoginFailed       = "login.failure"
+	AuditLogout            = "logout"
+	AuditPasswordChanged   = "password.change"
+	AuditPasswordReset     = "password.reset"
+	AuditEmailChanged      = "email.change"
+	AuditRoleChanged       = "role.change"
+	AuditTwoFactorEnabled  = "2fa.enable"
+	AuditTwoFactorDisabled = "2fa.disable"
+	AuditSessionRevoked    = "session.revoke"
+	AuditRefreshReused     = "session.refresh_reuse"
+	AuditTokenCreated      = "token.create"
+	AuditTokenRevoked      = "token.revoke"
+	AuditUserSuspended     = "account.suspend"
+	AuditUserUnsuspended   = "account.unsuspend"
+	AuditUserBanned        = "account.ban"
+	AuditUserUnbanned      = "account.unban"
+	AuditInviteCreated     = "invite.create"
+	AuditInviteRevoked     = "invite.revoke"
+	AuditReauthSucceeded   = "reauth.success"
+	AuditReauthFailed      = "reauth.failure"
+)
+
+// AuditEventTypes lists every event type, for validating query filters.
+var AuditEventTypes = []string{
+	AuditRegister, AuditOTPVerified, AuditOTPFailed, AuditLoginSucceeded,
+	AuditLoginFailed, AuditLogout, AuditPasswordChanged, AuditPasswordReset,
+	AuditEmailChanged, AuditRoleChanged, AuditTwoFactorEnabled,
+	AuditTwoFactorDisabled, AuditSessionRevoked, AuditRefreshReused,
+	AuditTokenCreated, AuditTokenRevoked, AuditUserSuspended,
+	AuditUserUnsuspended, AuditUserBanned, AuditUserUnbanned,
+	AuditInviteCreated, AuditInviteRevoked, AuditReauthSucceeded,
+	AuditReauthFailed,
+}
+
+// IsAuditEventType reports whether t is one of AuditEventTypes.
+func IsAuditEventType(t string) bool {
+	for _, known := range AuditEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordAuditEvent appends event to the audit_events collection.
+func RecordAuditEvent(ctx context.Context, client *mongo.Client, event models.AuditEvent) error {
+	event.ID = bson.NewObjectID()
+	event.CreatedAt = time.Now()
+
+	_, err := database.OpenCollection("audit_events", client).InsertOne(ctx, event)
+	return err
+}
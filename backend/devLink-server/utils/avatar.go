@@ -0,0 +1,313 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	_ "image/gif"
+)
+
+const (
+	// AvatarMaxBytes limits the size of an uploaded avatar file.
+	AvatarMaxBytes = 5 << 20
+	// avatarMaxPixels rejects images that are small on disk but would
+	// decode to an enormous bitmap.
+	avatarMaxPixels = 40_000_000
+)
+
+// AvatarSizes are the square edge lengths, in pixels, every uploaded avatar
+// is stored at. The first one is used as the profile image.
+var AvatarSizes = []int{256, 128, 64}
+
+var (
+	ErrUnsupportedImage = errors.New("only JPEG, PNG and GIF images are supported")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+// AvatarImage is one rendition of a processed avatar.
+type AvatarImage struct {
+	Size        int
+	Data        []byte
+	ContentType string
+	Ext         string
+}
+
+// ProcessAvatar turns an uploaded image into AvatarSizes square renditions.
+// The format is sniffed from the content rather than trusted from the
+// request, the EXIF orientation is applied, and the output is re-encoded from
+// pixels so that EXIF and other metadata (such as GPS positions) are dropped.
+// Photos are stored as JPEG, everything else as PNG to keep transparency.
+func ProcessAvatar(data []byte) ([]AvatarImage, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrUnsupportedImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > avatarMaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	if contentType == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	img = cropSquare(img)
+
+	var out []AvatarImage
+	for _, size := range AvatarSizes {
+		resized := resizeRGBA(img, size)
+
+		var buf bytes.Buffer
+		rendition := AvatarImage{Size: size}
+		if contentType == "image/jpeg" {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85})
+			rendition.ContentType, rendition.Ext = "image/jpeg", "jpg"
+		} else {
+			err = png.Encode(&buf, resized)
+			rendition.ContentType, rendition.Ext = "image/png", "png"
+		}
+		if err != nil {
+			return nil, err
+		}
+		rendition.Data = buf.Bytes()
+		out = append(out, rendition)
+	}
+	return out, nil
+}
+
+// cropSquare keeps the centred square of img.
+func cropSquare(img *image.RGBA) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w == h {
+		return img
+	}
+
+	side := min(w, h)
+	x0, y0 := (w-side)/2, (h-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x0, y0), draw.Src)
+	return dst
+}
+
+// resizeRGBA scales the square img to size×size. Each output pixel averages
+// the source pixels it covers, which keeps downscaled photos free of
+// aliasing; upscaling degrades to nearest neighbour.
+func resizeRGBA(img *image.RGBA, size int) *image.RGBA {
+	srcSize := img.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	for y := 0; y < size; y++ {
+		sy0 := y * srcSize / size
+		sy1 := max((y+1)*srcSize/size, sy0+1)
+
+		for x := 0; x < size; x++ {
+			sx0 := x * srcSize / size
+			sx1 := max((x+1)*srcSize/size, sx0+1)
+
+			var r, g, b, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				row := img.Pix[sy*img.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					b += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// applyOrientation rotates and flips img so that it displays upright given
+// its EXIF orientation tag (1-8).
+func applyOrientation(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs 90° clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // needs 90° counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], img.Pix[sy*img.Stride+sx*4:sy*img.Stride+sx*4+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG file, or 1 when it
+// has none or it cannot be read.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // image data or end: no EXIF
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation reads tag 0x0112 from the first IFD of a TIFF structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// LegacyInitialsAvatarPrefix is the third-party initials avatar users were
+// given before avatars were generated locally.
+const LegacyInitialsAvatarPrefix = "https://api.dicebear.com/7.x/initials/svg?seed="
+
+const initialsAvatarPath = "/avatars/initials.svg?seed="
+
+// InitialsAvatarURL is the address of the generated avatar for seed, served
+// from InitialsAvatarSVG.
+func InitialsAvatarURL(seed string) string {
+	return PublicAPIURL(initialsAvatarPath + url.QueryEscape(seed))
+}
+
+// IsInitialsAvatar reports whether profileImage is a generated avatar rather
+// than one the user picked or uploaded.
+func IsInitialsAvatar(profileImage string) bool {
+	return strings.HasPrefix(profileImage, PublicAPIURL(initialsAvatarPath)) ||
+		strings.HasPrefix(profileImage, LegacyInitialsAvatarPrefix)
+}
+
+// avatarColors are the backgrounds of generated avatars; white text must be
+// readable on all of them.
+var avatarColors = []string{
+	"#1e88e5", "#3949ab", "#5e35b1", "#8e24aa", "#d81b60",
+	"#e53935", "#f4511e", "#6d4c41", "#00897b", "#43a047",
+	"#546e7a", "#0277bd",
+}
+
+// InitialsAvatarSVG renders the default avatar for seed: up to two initials on
+// a background colour picked from seed, so the same name always gets the same
+// picture.
+func InitialsAvatarSVG(seed string) []byte {
+	var initials []rune
+	for _, word := range strings.FieldsFunc(seed, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		initials = append(initials, unicode.ToUpper([]rune(word)[0]))
+		if len(initials) == 2 {
+			break
+		}
+	}
+	if len(initials) == 0 {
+		initials = []rune{'?'}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	color := avatarColors[h.Sum32()%uint32(len(avatarColors))]
+
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="128" height="128" viewBox="0 0 128 128">`+
+		`<rect width="128" height="128" fill="%s"/>`+
+		`<text x="50%%" y="50%%" dy=".35em" fill="#ffffff" font-family="Arial,Helvetica,sans-serif" font-size="52" font-weight="600" text-anchor="middle">%s</text>`+
+		`</svg>`, color, html.EscapeString(string(initials))))
+}
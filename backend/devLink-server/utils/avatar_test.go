@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// halfAndHalf is a w×h image whose left half is red and right half blue.
+func halfAndHalf(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// withOrientation inserts an APP1 Exif segment holding only the orientation
+// tag right after the JPEG's SOI marker.
+func withOrientation(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8)) // first IFD
+	binary.Write(&tiff, binary.BigEndian, uint16(1)) // one entry
+	binary.Write(&tiff, binary.BigEndian, uint16(0x0112))
+	binary.Write(&tiff, binary.BigEndian, uint16(3)) // SHORT
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, orientation)
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+	binary.Write(&tiff, binary.BigEndian, uint32(0)) // no next IFD
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	var out bytes.Buffer
+	out.Write(jpg[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(jpg[2:])
+	return out.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return b > 0xC000 && r < 0x4000 && g < 0x4000
+}
+
+func TestProcessAvatarOrientedJPEG(t *testing.T) {
+	data := withOrientation(t, encodeJPEG(t, halfAndHalf(40, 20)), 6)
+	if got := jpegOrientation(data); got != 6 {
+		t.Fatalf("jpegOrientation = %d, want 6", got)
+	}
+
+	renditions, err := ProcessAvatar(data)
+	if err != nil {
+		t.Fatalf("ProcessAvatar: %v", err)
+	}
+	if len(renditions) != len(AvatarSizes) {
+		t.Fatalf("got %d renditions, want %d", len(renditions), len(AvatarSizes))
+	}
+
+	for i, r := range renditions {
+		if r.Size != AvatarSizes[i] || r.ContentType != "image/jpeg" || r.Ext != "jpg" {
+			t.Errorf("rendition %d = %d %s .%s, want %d image/jpeg .jpg", i, r.Size, r.ContentType, r.Ext, AvatarSizes[i])
+		}
+		if bytes.Contains(r.Data, []byte("Exif")) {
+			t.Errorf("rendition %d still carries EXIF data", r.Size)
+		}
+
+		img, err := jpeg.Decode(bytes.NewReader(r.Data))
+		if err != nil {
+			t.Fatalf("rendition %d does not decode: %v", r.Size, err)
+		}
+		if b := img.Bounds(); b.Dx() != r.Size || b.Dy() != r.Size {
+			t.Errorf("rendition %d is %dx%d", r.Size, b.Dx(), b.Dy())
+		}
+
+		// Turned a quarter clockwise, the left (red) half ends up on top.
+		mid := r.Size / 2
+		if top := img.At(mid, r.Size/8); !isRed(top) {
+			t.Errorf("rendition %d: top is %v, want red", r.Size, top)
+		}
+		if bottom := img.At(mid, r.Size*7/8); !isBlue(bottom) {
+			t.Errorf("rendition %d: bottom is %v, want blue", r.Size, bottom)
+		}
+	}
+}
+
+func TestProcessAvatarPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, halfAndHalf(300, 200)); err != nil {
+		t.Fatal(err)
+	}
+
+	renditions, err := ProcessAvatar(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ProcessAvatar: %v", err)
+	}
+
+	for _, r := range renditions {
+		if r.ContentType != "image/png" || r.Ext != "png" {
+			t.Errorf("rendition %d is %s .%s, want image/png .png", r.Size, r.ContentType, r.Ext)
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(r.Data))
+		if err != nil || cfg.Width != r.Size || cfg.Height != r.Size {
+			t.Errorf("rendition %d decodes to %dx%d (%v)", r.Size, cfg.Width, cfg.Height, err)
+		}
+	}
+}
+
+func TestProcessAvatarRejectsNonImages(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"),
+		[]byte("\xff\xd8\xff\xe0 truncated"),
+		nil,
+	} {
+		if _, err := ProcessAvatar(data); !errors.Is(err, ErrUnsupportedImage) {
+			t.Errorf("ProcessAvatar(%q) err = %v, want ErrUnsupportedImage", data, err)
+		}
+	}
+}
+
+func TestApplyOrientationDimensions(t *testing.T) {
+	src := halfAndHalf(4, 2)
+	for orientation := 1; orientation <= 8; orientation++ {
+		b := applyOrientation(src, orientation).Bounds()
+		wantW, wantH := 4, 2
+		if orientation >= 5 {
+			wantW, wantH = 2, 4
+		}
+		if b.Dx() != wantW || b.Dy() != wantH {
+			t.Errorf("orientation %d: %dx%d, want %dx%d", orientation, b.Dx(), b.Dy(), wantW, wantH)
+		}
+	}
+}
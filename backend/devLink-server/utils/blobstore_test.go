@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestValidBlobKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"avatars/u1/v1/256.jpg", true},
+		{"a.png", true},
+		{"a/..b/c", true},
+		{"", false},
+		{"/avatars/a.jpg", false},
+		{"..", false},
+		{"../a", false},
+		{"a/../b", false},
+		{"a/..", false},
+		{"./a", false},
+		{"a/./b", false},
+		{"a//b", false},
+		{"a/", false},
+		{`a\b`, false},
+		{`..\a`, false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidBlobKey(tt.key); got != tt.want {
+			t.Errorf("ValidBlobKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLocalBlobStoreRoundTrip(t *testing.T) {
+	store := &LocalBlobStore{Dir: t.TempDir()}
+	ctx := context.Background()
+	data := []byte("png bytes")
+
+	if err := store.Put(ctx, "avatars/u1/v1/64.png", data, "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	body, contentType, err := store.Get(ctx, "avatars/u1/v1/64.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if !bytes.Equal(got, data) || contentType != "image/png" {
+		t.Errorf("Get = %q, %q; want %q, image/png", got, contentType, data)
+	}
+
+	if err := store.Delete(ctx, "avatars/u1/v1/64.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "avatars/u1/v1/64.png"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrBlobNotFound", err)
+	}
+	if err := store.Delete(ctx, "avatars/u1/v1/64.png"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+
+	if err := store.Put(ctx, "../escape.png", data, "image/png"); err == nil {
+		t.Error("Put accepted a key outside the store")
+	}
+}
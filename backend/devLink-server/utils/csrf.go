@@ -0,0 +1,20 @@
+package utils
+
+import "crypto/subtle"
+
+const (
+	// CSRFCookie holds the double-submit token. It is readable by scripts on
+	// purpose: the client echoes it back in CSRFHeader.
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// ValidCSRFToken reports whether the token sent in the header matches the one
+// in the cookie. A cross-site page can make the browser send the cookie but
+// cannot read it, so it cannot produce the header.
+func ValidCSRFToken(cookie, header string) bool {
+	if cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
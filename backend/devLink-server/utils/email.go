@@ -1,102 +1,106 @@
 package utils
 
 import (
-	"fmt"
-	"net/smtp"
-	"os"
+	"context"
+	"time"
 )
 
-func SendOTPEmail(toEmail string, otp string) error {
+// otpValidMinutes is how long verification and reset codes stay valid.
+const otpValidMinutes = 10
 
-	from := os.Getenv("EMAIL_FROM")
-	password := os.Getenv("EMAIL_PASSWORD")
+type OTPEmailData struct {
+	OTP          string
+	ValidMinutes int
+}
+
+type LinkEmailData struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+type ChatRequestEmailData struct {
+	SenderName string
+	URL        string
+}
+
+type DigestPost struct {
+	Title  string
+	Author string
+	Views  int64
+	URL    string
+}
+
+type WeeklyDigestEmailData struct {
+	Name            string
+	Posts           []DigestPost
+	PendingRequests int64
+	URL             string
+}
 
-	if from == "" || password == "" {
-		return fmt.Errorf("email credentials not set")
+func SendOTPEmail(ctx context.Context, mailer Mailer, key, toEmail, locale, otp string) error {
+	return sendTemplate(ctx, mailer, key, toEmail, EmailOTP, locale, OTPEmailData{OTP: otp, ValidMinutes: otpValidMinutes})
+}
+
+func SendPasswordResetEmail(ctx context.Context, mailer Mailer, key, toEmail, locale, otp string) error {
+	return sendTemplate(ctx, mailer, key, toEmail, EmailPasswordReset, locale, OTPEmailData{OTP: otp, ValidMinutes: otpValidMinutes})
+}
+
+func SendMagicLinkEmail(ctx context.Context, mailer Mailer, key, toEmail, locale, loginURL string, expiresAt time.Time) error {
+	return sendTemplate(ctx, mailer, key, toEmail, EmailMagicLink, locale, LinkEmailData{URL: loginURL, ExpiresAt: expiresAt})
+}
+
+func SendExportReadyEmail(ctx context.Context, mailer Mailer, key, toEmail, locale, downloadURL string, expiresAt time.Time) error {
+	return sendTemplate(ctx, mailer, key, toEmail, EmailExportReady, locale, LinkEmailData{URL: downloadURL, ExpiresAt: expiresAt})
+}
+
+func SendChatRequestEmail(ctx context.Context, mailer Mailer, key, toEmail, locale, senderName string) error {
+	return sendTemplate(ctx, mailer, key, toEmail, EmailChatRequest, locale, ChatRequestEmailData{
+		SenderName: senderName,
+		URL:        FrontendURL("/dashboard"),
+	})
+}
+
+func SendWeeklyDigestEmail(ctx context.Context, mailer Mailer, key, toEmail, locale string, data WeeklyDigestEmailData) error {
+	return sendTemplate(ctx, mailer, key, toEmail, EmailWeeklyDigest, locale, data)
+}
+
+// sendTemplate renders template name and hands it to mailer. key is the
+// message's idempotency key; see Message.IdempotencyKey.
+func sendTemplate(ctx context.Context, mailer Mailer, key, toEmail, name, locale string, data interface{}) error {
+	msg, err := RenderEmail(name, locale, data)
+	if err != nil {
+		return err
 	}
+	msg.To = toEmail
+	msg.Template = name
+	msg.IdempotencyKey = key
+	return mailer.Send(ctx, msg)
+}
 
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587"
-
-	subject := "DevLink • Verify your email"
-
-	// ---------- HTML EMAIL BODY ----------
-	body := fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8" />
-  <meta name="viewport" content="width=device-width, initial-scale=1.0"/>
-</head>
-<body style="margin:0; padding:0; background:#0f172a; font-family:Arial, Helvetica, sans-serif;">
-  <div style="max-width:520px; margin:40px auto; background:#111827; border-radius:16px; padding:28px; color:#ffffff;">
-    
-    <!-- Header -->
-    <h2 style="text-align:center; margin:0;">
-      Dev<span style="color:#3b82f6;">Link</span>
-    </h2>
-
-    <p style="text-align:center; color:#94a3b8; font-size:14px; margin-top:6px;">
-      Email verification required
-    </p>
-
-    <!-- Content -->
-    <div style="margin:32px 0; text-align:center;">
-      <p style="font-size:14px; color:#cbd5f5; margin-bottom:16px;">
-        Use the following One-Time Password (OTP) to verify your account:
-      </p>
-
-      <div style="
-        display:inline-block;
-        background:#020617;
-        padding:16px 32px;
-        border-radius:12px;
-        font-size:28px;
-        font-weight:bold;
-        letter-spacing:6px;
-        color:#3b82f6;
-        margin-bottom:12px;
-      ">
-        %s
-      </div>
-
-      <p style="font-size:12px; color:#94a3b8;">
-        This OTP is valid for <strong>10 minutes</strong>.
-      </p>
-    </div>
-
-    <!-- Footer -->
-    <hr style="border:none; border-top:1px solid #1e293b; margin:24px 0;" />
-
-    <p style="font-size:12px; color:#64748b; text-align:center; line-height:1.6;">
-      If you did not request this verification, you can safely ignore this email.
-      <br />
-      © DevLink
-    </p>
-
-  </div>
-</body>
-</html>
-`, otp)
-
-	message := []byte(
-		"From: DevLink <" + from + ">\r\n" +
-			"To: " + toEmail + "\r\n" +
-			"Subject: " + subject + "\r\n" +
-			"MIME-Version: 1.0\r\n" +
-			"Content-Type: text/html; charset=\"UTF-8\"\r\n\r\n" +
-			body,
-	)
-
-	auth := smtp.PlainAuth("", from, password, smtpHost)
-
-	err := smtp.SendMail(
-		smtpHost+":"+smtpPort,
-		auth,
-		from,
-		[]string{toEmail},
-		message,
-	)
-
-	return err
+// SampleEmailData returns placeholder data for previewing template name.
+func SampleEmailData(name string) (interface{}, bool) {
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	switch name {
+	case EmailOTP, EmailPasswordReset:
+		return OTPEmailData{OTP: "482913", ValidMinutes: otpValidMinutes}, true
+	case EmailMagicLink:
+		return LinkEmailData{URL: FrontendURL("/magic-link?token=preview"), ExpiresAt: expiresAt}, true
+	case EmailExportReady:
+		return LinkEmailData{URL: FrontendURL("/exports/preview"), ExpiresAt: expiresAt.Add(7 * 24 * time.Hour)}, true
+	case EmailChatRequest:
+		return ChatRequestEmailData{SenderName: "Ada Lovelace", URL: FrontendURL("/dashboard")}, true
+	case EmailWeeklyDigest:
+		return WeeklyDigestEmailData{
+			Name: "Grace Hopper",
+			Posts: []DigestPost{
+				{Title: "Writing a compiler in a weekend", Author: "Ada Lovelace", Views: 1280, URL: FrontendURL("/post/writing-a-compiler")},
+				{Title: "Go generics in practice", Author: "Rob Pike", Views: 864, URL: FrontendURL("/post/go-generics")},
+			},
+			PendingRequests: 2,
+			URL:             FrontendURL("/dashboard"),
+		}, true
+	default:
+		return nil, false
+	}
 }
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// QueueMailer is the Mailer handed to controllers and workers. Send only
+// stores the message in the outbound_emails collection; workers.RunEmailQueue
+// delivers it through the real transport.
+type QueueMailer struct {
+	client *mongo.Client
+}
+
+func NewQueueMailer(client *mongo.Client) *QueueMailer {
+	return &QueueMailer{client: client}
+}
+
+// Send enqueues msg. A message whose IdempotencyKey was already enqueued is
+// silently dropped.
+func (q *QueueMailer) Send(ctx context.Context, msg Message) error {
+	_, err := EnqueueEmail(ctx, q.client, msg)
+	return err
+}
+
+// EnqueueEmail stores msg for delivery and returns the queued record. For a
+// duplicate IdempotencyKey the existing record is returned instead.
+func EnqueueEmail(ctx context.Context, client *mongo.Client, msg Message) (*models.OutboundEmail, error) {
+	now := time.Now()
+	email := models.OutboundEmail{
+		ID:             bson.NewObjectID(),
+		IdempotencyKey: msg.IdempotencyKey,
+		To:             msg.To,
+		Subject:        msg.Subject,
+		Template:       msg.Template,
+		Text:           msg.Text,
+		HTML:           msg.HTML,
+		Status:         models.EmailQueued,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+
+	col := database.OpenCollection("outbound_emails", client)
+
+	_, err := col.InsertOne(ctx, email)
+	if mongo.IsDuplicateKeyError(err) && msg.IdempotencyKey != "" {
+		var existing models.OutboundEmail
+		if err := col.FindOne(ctx, bson.M{"idempotency_key": msg.IdempotencyKey}).Decode(&existing); err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &email, nil
+}
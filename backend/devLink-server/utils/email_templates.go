@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/email/*.html templates/email/*.txt
+var emailTemplateFS embed.FS
+
+// DefaultLocale is used when a user has no locale or their locale has no
+// translation of a template.
+const DefaultLocale = "en"
+
+// SupportedLocales lists the locales every email template is translated to.
+var SupportedLocales = []string{"en", "es"}
+
+// Email template names. Each one has a <name>.<locale>.html file that fills
+// the blocks of layout.html, and a <name>.<locale>.txt file defining the
+// "subject" and plain-text "body".
+const (
+	EmailOTP           = "otp"
+	EmailPasswordReset = "password_reset"
+	EmailMagicLink     = "magic_link"
+	EmailExportReady   = "export_ready"
+	EmailChatRequest   = "chat_request"
+	EmailWeeklyDigest  = "weekly_digest"
+)
+
+// EmailTemplateNames lists every registered template.
+var EmailTemplateNames = []string{
+	EmailOTP,
+	EmailPasswordReset,
+	EmailMagicLink,
+	EmailExportReady,
+	EmailChatRequest,
+	EmailWeeklyDigest,
+}
+
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// emailTemplates is keyed by template name, then locale. Templates are parsed
+// once at start-up; a broken template is a build mistake and panics.
+var emailTemplates = mustLoadEmailTemplates()
+
+func mustLoadEmailTemplates() map[string]map[string]*emailTemplate {
+	registry := make(map[string]map[string]*emailTemplate)
+
+	for _, name := range EmailTemplateNames {
+		registry[name] = make(map[string]*emailTemplate)
+
+		for _, locale := range SupportedLocales {
+			base := "templates/email/" + name + "." + locale
+
+			html := htmltemplate.Must(
+				htmltemplate.New("layout").
+					Funcs(htmltemplate.FuncMap(emailFuncs(locale))).
+					Funcs(htmltemplate.FuncMap{"button": emailButton}).
+					ParseFS(emailTemplateFS, "templates/email/layout.html", base+".html"),
+			)
+			text := texttemplate.Must(
+				texttemplate.New("text").
+					Funcs(texttemplate.FuncMap(emailFuncs(locale))).
+					ParseFS(emailTemplateFS, base+".txt"),
+			)
+
+			registry[name][locale] = &emailTemplate{html: html, text: text}
+		}
+	}
+
+	return registry
+}
+
+func emailFuncs(locale string) map[string]interface{} {
+	return map[string]interface{}{
+		"locale": func() string { return locale },
+		"time": func(t time.Time) string {
+			return t.UTC().Format("15:04 MST")
+		},
+		"datetime": func(t time.Time) string {
+			return t.UTC().Format("2 Jan 2006 15:04 MST")
+		},
+	}
+}
+
+func emailButton(url, label string) htmltemplate.HTML {
+	return htmltemplate.HTML(fmt.Sprintf(`<a href="%s" style="
+        display:inline-block;
+        background:#3b82f6;
+        padding:14px 28px;
+        border-radius:12px;
+        font-size:16px;
+        font-weight:bold;
+        color:#ffffff;
+        text-decoration:none;
+      ">%s</a>`,
+		htmltemplate.HTMLEscapeString(url),
+		htmltemplate.HTMLEscapeString(label),
+	))
+}
+
+// MatchLocale picks the best supported locale for an Accept-Language header
+// or a stored user preference, falling back to DefaultLocale.
+func MatchLocale(preference string) string {
+	for _, part := range strings.Split(preference, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+
+		for _, locale := range SupportedLocales {
+			if lang == locale {
+				return locale
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// RenderEmail renders template name in the given locale. The returned message
+// has no recipient yet.
+func RenderEmail(name, locale string, data interface{}) (Message, error) {
+	variants, ok := emailTemplates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	tmpl, ok := variants[MatchLocale(locale)]
+	if !ok {
+		tmpl = variants[DefaultLocale]
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "body", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrSelfFollow = errors.New("users cannot follow themselves")
+
+// Follow makes followerID follow followeeID and reports whether the edge is
+// new. Following twice is a no-op.
+//
+// The edge and both followers_count and following_count counters are written
+// in one transaction, so a failure leaves neither behind and a retry starts
+// from scratch. The unique index on (follower_id, followee_id) decides which
+// of several concurrent requests creates the edge.
+func Follow(ctx context.Context, client *mongo.Client, followerID, followeeID bson.ObjectID) (bool, error) {
+	if followerID == followeeID {
+		return false, ErrSelfFollow
+	}
+
+	err := inTransaction(ctx, client, func(ctx context.Context) error {
+		if _, err := database.OpenCollection("follows", client).InsertOne(ctx, models.Follow{
+			ID:         bson.NewObjectID(),
+			FollowerID: followerID,
+			FolloweeID: followeeID,
+			CreatedAt:  time.Now(),
+		}); err != nil {
+			return err
+		}
+		return adjustFollowCounts(ctx, client, followerID, followeeID, 1)
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Unfollow removes the edge from followerID to followeeID and reports whether
+// there was one. Like Follow, the edge and the counters change together.
+func Unfollow(ctx context.Context, client *mongo.Client, followerID, followeeID bson.ObjectID) (bool, error) {
+	var removed bool
+	err := inTransaction(ctx, client, func(ctx context.Context) error {
+		result, err := database.OpenCollection("follows", client).DeleteOne(ctx, bson.M{
+			"follower_id": followerID,
+			"followee_id": followeeID,
+		})
+		if err != nil {
+			return err
+		}
+		removed = result.DeletedCount > 0
+		if !removed {
+			return nil
+		}
+		return adjustFollowCounts(ctx, client, followerID, followeeID, -1)
+	})
+	if err != nil {
+		return false, err
+	}
+	return removed, nil
+}
+
+// IsFollowing reports whether followerID follows followeeID.
+func IsFollowing(ctx context.Context, client *mongo.Client, followerID, followeeID bson.ObjectID) (bool, error) {
+	count, err := database.OpenCollection("follows", client).CountDocuments(ctx, bson.M{
+		"follower_id": followerID,
+		"followee_id": followeeID,
+	})
+	return count > 0, err
+}
+
+func adjustFollowCounts(ctx context.Context, client *mongo.Client, followerID, followeeID bson.ObjectID, delta int) error {
+	users := database.OpenCollection("users", client)
+
+	if _, err := users.UpdateOne(ctx, bson.M{"_id": followeeID}, bson.M{"$inc": bson.M{"followers_count": delta}}); err != nil {
+		return err
+	}
+	_, err := users.UpdateOne(ctx, bson.M{"_id": followerID}, bson.M{"$inc": bson.M{"following_count": delta}})
+	return err
+}
+
+// inTransaction runs fn in a transaction, retrying it on transient errors such
+// as write conflicts between concurrent requests.
+func inTransaction(ctx context.Context, client *mongo.Client, fn func(ctx context.Context) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// HandleChangeCooldown is how long a user has to wait between handle changes.
+const HandleChangeCooldown = 30 * 24 * time.Hour
+
+// HandleRedirectTTL is how long a released handle keeps redirecting to its
+// previous owner. Until then nobody else can claim it.
+const HandleRedirectTTL = 90 * 24 * time.Hour
+
+const (
+	minHandleLength = 3
+	maxHandleLength = 20
+)
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// reservedHandles cannot be claimed because they collide with routes, roles or
+// could be used to impersonate the service.
+var reservedHandles = map[string]bool{
+	"admin": true, "administrator": true, "api": true, "auth": true,
+	"devlink": true, "help": true, "home": true, "login": true,
+	"logout": true, "me": true, "moderator": true, "null": true,
+	"posts": true, "register": true, "root": true, "security": true,
+	"settings": true, "signup": true, "staff": true, "support": true,
+	"system": true, "undefined": true, "user": true, "users": true,
+	"ws": true,
+}
+
+var (
+	ErrHandleInvalid  = errors.New("handle must be 3-20 characters, start with a letter and contain only letters, digits and underscores")
+	ErrHandleReserved = errors.New("handle is reserved")
+	ErrHandleTaken    = errors.New("handle is already taken")
+)
+
+// NormalizeHandle returns the case-folded form handles are compared by. A
+// leading @ is ignored.
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// ValidateHandle checks the format rules and the reserved-word list.
+func ValidateHandle(handle string) error {
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength || !handlePattern.MatchString(handle) {
+		return ErrHandleInvalid
+	}
+	if reservedHandles[strings.ToLower(handle)] {
+		return ErrHandleReserved
+	}
+	return nil
+}
+
+// IsHandleAvailable reports whether handle can be claimed by userID: no other
+// user holds it, and it is not reserved as a redirect to another user.
+func IsHandleAvailable(ctx context.Context, client *mongo.Client, handle string, userID bson.ObjectID) (bool, error) {
+	lower := NormalizeHandle(handle)
+
+	count, err := database.OpenCollection("users", client).CountDocuments(ctx, bson.M{
+		"handle_lower": lower,
+		"_id":          bson.M{"$ne": userID},
+	})
+	if err != nil || count > 0 {
+		return false, err
+	}
+
+	count, err = database.OpenCollection("handle_redirects", client).CountDocuments(ctx, bson.M{
+		"_id":        lower,
+		"user_id":    bson.M{"$ne": userID},
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// SuggestHandle derives a free, valid handle from a display name or email
+// local part, appending digits when the plain form is taken.
+func SuggestHandle(ctx context.Context, client *mongo.Client, seed string, userID bson.ObjectID) (string, error) {
+	base := sanitizeHandleSeed(seed)
+
+	candidates := []string{base}
+	for i := 0; i < 20; i++ {
+		suffix := fmt.Sprintf("%d", rand.Intn(10000))
+		trimmed := base
+		if len(trimmed)+len(suffix) > maxHandleLength {
+			trimmed = trimmed[:maxHandleLength-len(suffix)]
+		}
+		candidates = append(candidates, trimmed+suffix)
+	}
+
+	for _, candidate := range candidates {
+		if ValidateHandle(candidate) != nil {
+			continue
+		}
+		ok, err := IsHandleAvailable(ctx, client, candidate, userID)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return candidate, nil
+		}
+	}
+
+	return "", ErrHandleTaken
+}
+
+func sanitizeHandleSeed(seed string) string {
+	if at := strings.Index(seed, "@"); at > 0 {
+		seed = seed[:at]
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(seed) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '_' || r == ' ' || r == '-' || r == '.':
+			b.WriteRune('_')
+		}
+	}
+
+	handle := strings.Trim(b.String(), "_")
+	for strings.Contains(handle, "__") {
+		handle = strings.ReplaceAll(handle, "__", "_")
+	}
+	if handle == "" || handle[0] < 'a' || handle[0] > 'z' {
+		handle = "dev" + handle
+	}
+	if len(handle) > maxHandleLength {
+		handle = strings.TrimRight(handle[:maxHandleLength], "_")
+	}
+	for len(handle) < minHandleLength {
+		handle += "_"
+	}
+	return handle
+}
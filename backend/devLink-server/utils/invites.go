@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// inviteAlphabet leaves out characters that are easy to misread (0/O, 1/I/L).
+const inviteAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const inviteCodeLength = 12
+
+var ErrInvalidInvite = errors.New("invite code is invalid, expired or used up")
+
+// GenerateInviteCode returns a new code formatted as XXXX-XXXX-XXXX.
+func GenerateInviteCode() (string, error) {
+	// Bytes at or above limit are skipped so every character is equally
+	// likely.
+	limit := 256 - 256%len(inviteAlphabet)
+
+	var code strings.Builder
+	b := make([]byte, 1)
+	for n := 0; n < inviteCodeLength; {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		if int(b[0]) >= limit {
+			continue
+		}
+		if n > 0 && n%4 == 0 {
+			code.WriteByte('-')
+		}
+		code.WriteByte(inviteAlphabet[int(b[0])%len(inviteAlphabet)])
+		n++
+	}
+	return code.String(), nil
+}
+
+// NormalizeInviteCode upper-cases code and drops separators, so that codes
+// can be typed in any case with or without dashes.
+func NormalizeInviteCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// HashInviteCode returns the digest invites are looked up by.
+func HashInviteCode(code string) string {
+	return HashToken(NormalizeInviteCode(code))
+}
+
+// RedeemInvite uses up one slot of the invite with code for userID. The check
+// and the increment are a single update, so concurrent registrations cannot
+// exceed the quota.
+func RedeemInvite(ctx context.Context, client *mongo.Client, code string, userID bson.ObjectID, email string) (*models.Invite, error) {
+	now := time.Now()
+
+	var invite models.Invite
+	err := database.OpenCollection("invites", client).FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"code_hash":  HashInviteCode(code),
+			"revoked_at": bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": now},
+			"$expr":      bson.M{"$lt": bson.A{"$uses", "$max_uses"}},
+		},
+		bson.M{
+			"$inc": bson.M{"uses": 1},
+			"$push": bson.M{"redemptions": models.InviteRedemption{
+				UserID:     userID,
+				Email:      email,
+				RedeemedAt: now,
+			}},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&invite)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidInvite
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ReleaseInvite gives back the slot RedeemInvite took for userID, for when the
+// registration it was taken for did not go through.
+func ReleaseInvite(ctx context.Context, client *mongo.Client, inviteID, userID bson.ObjectID) error {
+	_, err := database.OpenCollection("invites", client).UpdateOne(
+		ctx,
+		bson.M{"_id": inviteID, "redemptions.user_id": userID},
+		bson.M{
+			"$inc":  bson.M{"uses": -1},
+			"$pull": bson.M{"redemptions": bson.M{"user_id": userID}},
+		},
+	)
+	return err
+}
@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Tokens are signed with Ed25519 keys kept in the signing_keys collection so
+// that every instance shares the same key ring. A new key is created every
+// rotation interval; older keys stay published for verification for another
+// interval and are then removed by a TTL index.
+
+const defaultKeyRotationInterval = 30 * 24 * time.Hour
+
+// keyReloadInterval is how often every instance reloads the key ring. A new
+// key is only used for signing once it is this old, so that all instances
+// (and JWKS consumers) know it before the first token signed with it arrives.
+const keyReloadInterval = time.Hour
+
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+type signingKey struct {
+	ID        string
+	Private   ed25519.PrivateKey
+	Public    ed25519.PublicKey
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type storedSigningKey struct {
+	ID        string    `bson:"_id"`
+	Seed      string    `bson:"seed"`
+	CreatedAt time.Time `bson:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+type keyRing struct {
+	mu      sync.RWMutex
+	keys    map[string]*signingKey
+	current *signingKey
+	newest  *signingKey
+}
+
+var ring = &keyRing{keys: map[string]*signingKey{}}
+
+// KeyRotationInterval is read from JWT_KEY_ROTATION_INTERVAL (a Go duration,
+// e.g. "720h") and defaults to 30 days.
+func KeyRotationInterval() time.Duration {
+	if v := os.Getenv("JWT_KEY_ROTATION_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Println("warning: invalid JWT_KEY_ROTATION_INTERVAL, using default")
+	}
+	return defaultKeyRotationInterval
+}
+
+// InitKeyRing loads the signing keys and creates the first one if needed. It
+// must run before any token is issued or verified.
+func InitKeyRing(client *mongo.Client) error {
+	return rotateKeys(client)
+}
+
+// RunKeyRotation periodically reloads the key ring and rotates the signing key
+// once it is older than KeyRotationInterval. It never returns.
+func RunKeyRotation(client *mongo.Client) {
+	ticker := time.NewTicker(keyReloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := rotateKeys(client); err != nil {
+			log.Println("KEY ROTATION FAILED:", err)
+		}
+	}
+}
+
+func rotateKeys(client *mongo.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	interval := KeyRotationInterval()
+
+	if err := loadKeys(ctx, client); err != nil {
+		return err
+	}
+
+	ring.mu.RLock()
+	newest := ring.newest
+	ring.mu.RUnlock()
+
+	if newest != nil && time.Since(newest.CreatedAt) < interval {
+		return nil
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	stored := storedSigningKey{
+		ID:        bson.NewObjectID().Hex(),
+		Seed:      base64.StdEncoding.EncodeToString(seed),
+		CreatedAt: now,
+		ExpiresAt: now.Add(2 * interval),
+	}
+
+	if _, err := database.OpenCollection("signing_keys", client).InsertOne(ctx, stored); err != nil {
+		return err
+	}
+	log.Println("JWT signing key rotated, kid:", stored.ID)
+
+	return loadKeys(ctx, client)
+}
+
+func loadKeys(ctx context.Context, client *mongo.Client) error {
+	cursor, err := database.OpenCollection("signing_keys", client).Find(
+		ctx,
+		bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+
+	var stored []storedSigningKey
+	if err := cursor.All(ctx, &stored); err != nil {
+		return err
+	}
+
+	keys := map[string]*signingKey{}
+	var current, newest *signingKey
+
+	for _, s := range stored {
+		seed, err := base64.StdEncoding.DecodeString(s.Seed)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			log.Println("warning: skipping malformed signing key", s.ID)
+			continue
+		}
+
+		private := ed25519.NewKeyFromSeed(seed)
+		key := &signingKey{
+			ID:        s.ID,
+			Private:   private,
+			Public:    private.Public().(ed25519.PublicKey),
+			CreatedAt: s.CreatedAt,
+			ExpiresAt: s.ExpiresAt,
+		}
+		keys[key.ID] = key
+
+		// Sorted by creation, so the newest published key wins.
+		newest = key
+		if current == nil || time.Since(key.CreatedAt) >= keyReloadInterval {
+			current = key
+		}
+	}
+
+	ring.mu.Lock()
+	ring.keys = keys
+	ring.current = current
+	ring.newest = newest
+	ring.mu.Unlock()
+
+	return nil
+}
+
+func currentSigningKey() (*signingKey, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if ring.current == nil {
+		return nil, ErrUnknownSigningKey
+	}
+	return ring.current, nil
+}
+
+func verificationKey(kid string) (ed25519.PublicKey, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	key, ok := ring.keys[kid]
+	if !ok || time.Now().After(key.ExpiresAt) {
+		return nil, ErrUnknownSigningKey
+	}
+	return key.Public, nil
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKS returns every public key that tokens may currently be signed with.
+func JWKS() []JWK {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	keys := []JWK{}
+	for _, key := range ring.keys {
+		if time.Now().After(key.ExpiresAt) {
+			continue
+		}
+		keys = append(keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.Public),
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+		})
+	}
+	return keys
+}
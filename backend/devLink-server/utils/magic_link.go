@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// MagicLinkTTL is how long an emailed login link stays valid.
+const MagicLinkTTL = 15 * time.Minute
+
+const magicLinkPurpose = "magic_link"
+
+var (
+	ErrInvalidMagicLink = errors.New("invalid or expired magic link")
+	ErrMagicLinkBrowser = errors.New("magic link was requested from another browser")
+)
+
+// CreateMagicLink signs a login link token for the user and records it as
+// unused. nonce is the value of the requesting browser's nonce cookie; only
+// its digest is stored.
+func CreateMagicLink(ctx context.Context, client *mongo.Client, userID bson.ObjectID, nonce, ip string) (string, time.Time, error) {
+	jti, err := generateTokenID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(MagicLinkTTL)
+
+	token, err := signToken(PurposeClaims{
+		UserID:  userID.Hex(),
+		Purpose: magicLinkPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    tokenIssuer,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	link := models.MagicLink{
+		ID:        bson.NewObjectID(),
+		UserID:    userID,
+		TokenID:   jti,
+		NonceHash: HashToken(nonce),
+		RequestIP: ip,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+
+	if _, err := database.OpenCollection("magic_links", client).InsertOne(ctx, link); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// ConsumeMagicLink verifies a login link token presented together with the
+// browser's nonce and marks it used. A link presented from another browser is
+// rejected without being used up.
+func ConsumeMagicLink(ctx context.Context, client *mongo.Client, token, nonce string) (bson.ObjectID, error) {
+	claims, err := VerifyPurposeToken(token, magicLinkPurpose)
+	if err != nil {
+		return bson.ObjectID{}, ErrInvalidMagicLink
+	}
+
+	linkCol := database.OpenCollection("magic_links", client)
+	now := time.Now()
+
+	var link models.MagicLink
+	err = linkCol.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"token_id":   claims.ID,
+			"nonce_hash": HashToken(nonce),
+			"used_at":    bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"used_at": now}},
+	).Decode(&link)
+
+	if err == nil {
+		return link.UserID, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return bson.ObjectID{}, err
+	}
+
+	count, err := linkCol.CountDocuments(ctx, bson.M{
+		"token_id":   claims.ID,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return bson.ObjectID{}, err
+	}
+	if count > 0 {
+		return bson.ObjectID{}, ErrMagicLinkBrowser
+	}
+
+	return bson.ObjectID{}, ErrInvalidMagicLink
+}
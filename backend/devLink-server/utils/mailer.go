@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is one outgoing email. When both Text and HTML are set it is sent
+// as multipart/alternative so clients can pick either.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+
+	// Template names the email template the message was rendered from.
+	Template string
+	// IdempotencyKey identifies the logical message, so that queueing it
+	// again (e.g. when a request is retried) does not send it twice.
+	IdempotencyKey string
+}
+
+// Mailer delivers email. Controllers and workers receive a Mailer instead of
+// talking to an SMTP server themselves; in production that is a QueueMailer,
+// and development and tests can swap in ConsoleMailer or MemoryMailer.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTP security modes.
+const (
+	SMTPStartTLS = "starttls"
+	SMTPTLS      = "tls"
+	SMTPNone     = "none"
+)
+
+// SMTPMailer sends mail through an SMTP server. Security selects STARTTLS on a
+// plain connection (usually port 587), implicit TLS (usually port 465) or no
+// encryption at all, which is only meant for local mail catchers.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Security string
+	Username string
+	Password string
+	From     string
+	FromName string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(m.Host, m.Port)
+	tlsConfig := &tls.Config{ServerName: m.Host}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if m.Security == SMTPTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if m.Security == SMTPStartTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if m.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(m.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(formatMessage(m.From, m.FromName, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// ConsoleMailer is a development sink. Instead of sending a message it prints
+// its plain-text version to Out and, when Dir is set, saves the full MIME
+// message there as an .eml file.
+type ConsoleMailer struct {
+	Out io.Writer
+	Dir string
+}
+
+func (m *ConsoleMailer) Send(ctx context.Context, msg Message) error {
+	if m.Out != nil {
+		fmt.Fprintf(m.Out, "----- email to %s -----\nSubject: %s\n\n%s\n----- end of email -----\n", msg.To, msg.Subject, msg.Text)
+	}
+
+	if m.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	token, err := generateTokenID()
+	if err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format("20060102T150405") + "-" + token[:8] + ".eml"
+	return os.WriteFile(filepath.Join(m.Dir, name), formatMessage("devlink@localhost", "DevLink", msg), 0o644)
+}
+
+// MemoryMailer records messages instead of sending them, for tests.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of everything sent so far.
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// Reset forgets all recorded messages.
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
+
+// NewMailerFromEnv builds the Mailer selected by MAIL_TRANSPORT: "smtp" (the
+// default), "console", "file" or "memory".
+//
+// SMTP is configured through SMTP_HOST, SMTP_PORT, SMTP_SECURITY, SMTP_USERNAME
+// and SMTP_PASSWORD. The defaults keep the previous behaviour of sending
+// through Gmail with EMAIL_FROM and EMAIL_PASSWORD. The file sink writes to
+// MAIL_DIR.
+func NewMailerFromEnv() (Mailer, error) {
+	switch transport := strings.ToLower(envOr("MAIL_TRANSPORT", "smtp")); transport {
+	case "smtp":
+		from := os.Getenv("EMAIL_FROM")
+		m := &SMTPMailer{
+			Host:     envOr("SMTP_HOST", "smtp.gmail.com"),
+			Port:     envOr("SMTP_PORT", "587"),
+			Security: strings.ToLower(envOr("SMTP_SECURITY", SMTPStartTLS)),
+			Username: envOr("SMTP_USERNAME", from),
+			Password: envOr("SMTP_PASSWORD", os.Getenv("EMAIL_PASSWORD")),
+			From:     from,
+			FromName: envOr("EMAIL_FROM_NAME", "DevLink"),
+		}
+		if m.From == "" {
+			return nil, errors.New("EMAIL_FROM is not set")
+		}
+		switch m.Security {
+		case SMTPStartTLS, SMTPTLS, SMTPNone:
+		default:
+			return nil, fmt.Errorf("unknown SMTP_SECURITY %q", m.Security)
+		}
+		return m, nil
+	case "console":
+		return &ConsoleMailer{Out: os.Stdout}, nil
+	case "file":
+		return &ConsoleMailer{Out: os.Stdout, Dir: envOr("MAIL_DIR", "./mail")}, nil
+	case "memory":
+		return &MemoryMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_TRANSPORT %q", transport)
+	}
+}
+
+type mimePart struct {
+	contentType string
+	body        string
+}
+
+func formatMessage(from, fromName string, msg Message) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("From: " + mime.QEncoding.Encode("utf-8", fromName) + " <" + from + ">\r\n")
+	buf.WriteString("To: " + msg.To + "\r\n")
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	var parts []mimePart
+	if msg.Text != "" {
+		parts = append(parts, mimePart{"text/plain", msg.Text})
+	}
+	if msg.HTML != "" {
+		parts = append(parts, mimePart{"text/html", msg.HTML})
+	}
+
+	if len(parts) == 1 {
+		buf.WriteString("Content-Type: " + parts[0].contentType + "; charset=\"UTF-8\"\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		writeQuotedPrintable(&buf, parts[0].body)
+		return buf.Bytes()
+	}
+
+	mw := multipart.NewWriter(&buf)
+	buf.WriteString("Content-Type: multipart/alternative; boundary=\"" + mw.Boundary() + "\"\r\n\r\n")
+
+	// Parts go from least to most preferred, so plain text comes first.
+	for _, part := range parts {
+		w, _ := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=\"UTF-8\""},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		writeQuotedPrintable(w, part.body)
+	}
+	mw.Close()
+
+	return buf.Bytes()
+}
+
+func writeQuotedPrintable(w io.Writer, body string) {
+	qp := quotedprintable.NewWriter(w)
+	qp.Write([]byte(body))
+	qp.Close()
+}
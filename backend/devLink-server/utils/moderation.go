@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AccountRestrictedError explains why a banned or suspended user was turned
+// away.
+type AccountRestrictedError struct {
+	Code   string
+	Reason string
+	Until  *time.Time
+}
+
+func (e *AccountRestrictedError) Error() string {
+	if e.Code == "ACCOUNT_BANNED" {
+		return "This account has been banned"
+	}
+	return "This account is suspended"
+}
+
+// Body is the JSON error response for e.
+func (e *AccountRestrictedError) Body() map[string]interface{} {
+	body := map[string]interface{}{
+		"error":  e.Error(),
+		"code":   e.Code,
+		"reason": e.Reason,
+	}
+	if e.Until != nil {
+		body["until"] = e.Until
+	}
+	return body
+}
+
+// AccountRestriction returns why user may not use their account right now, or
+// nil if they may. A suspension whose end date has passed no longer applies.
+func AccountRestriction(user models.User) *AccountRestrictedError {
+	if user.Ban != nil {
+		return &AccountRestrictedError{Code: "ACCOUNT_BANNED", Reason: user.Ban.Reason}
+	}
+	if s := user.Suspension; s != nil && (s.Until == nil || s.Until.After(time.Now())) {
+		return &AccountRestrictedError{Code: "ACCOUNT_SUSPENDED", Reason: s.Reason, Until: s.Until}
+	}
+	return nil
+}
+
+// LoadAccountRestriction is AccountRestriction for a user that has not been
+// loaded yet. A missing user is not restricted; callers deal with that.
+func LoadAccountRestriction(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (*AccountRestrictedError, error) {
+	var user models.User
+	err := database.OpenCollection("users", client).FindOne(
+		ctx,
+		bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"suspension": 1, "ban": 1}),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return AccountRestriction(user), nil
+}
+
+// RestrictedUserIDs returns the ids of every user who is currently banned or
+// suspended.
+func RestrictedUserIDs(ctx context.Context, client *mongo.Client) ([]bson.ObjectID, error) {
+	cursor, err := database.OpenCollection("users", client).Find(
+		ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"ban": bson.M{"$exists": true}},
+			bson.M{"suspension": bson.M{"$exists": true}},
+		}},
+		options.Find().SetProjection(bson.M{"suspension": 1, "ban": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	ids := []bson.ObjectID{}
+	for _, user := range users {
+		if AccountRestriction(user) != nil {
+			ids = append(ids, user.Id)
+		}
+	}
+	return ids, nil
+}
+
+// SetAuthorRestricted flags or unflags every post by userID as written by a
+// restricted account. Moderation calls it whenever a ban or suspension starts
+// or ends.
+func SetAuthorRestricted(ctx context.Context, client *mongo.Client, userID bson.ObjectID, restricted bool) error {
+	update := bson.M{"$unset": bson.M{"author_restricted": ""}}
+	if restricted {
+		update = bson.M{"$set": bson.M{"author_restricted": true}}
+	}
+	_, err := database.OpenCollection("posts", client).UpdateMany(ctx, bson.M{"author_id": userID}, update)
+	return err
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrOAuthProviderNotConfigured = errors.New("oauth provider not configured")
+
+// OAuthProfile is the part of an external account DevLink cares about.
+type OAuthProfile struct {
+	Subject       string
+	Username      string
+	Name          string
+	Email         string
+	EmailVerified bool
+	AvatarURL     string
+}
+
+// OAuthProvider is an OAuth2 authorization-code provider. All endpoints are
+// configurable so that a provider can be pointed at a local stub.
+type OAuthProvider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	RedirectURL  string
+	Scopes       []string
+
+	// FetchProfile loads the signed-in user's profile with an access token.
+	FetchProfile func(ctx context.Context, p *OAuthProvider, accessToken string) (*OAuthProfile, error)
+
+	apiURL string
+}
+
+var oauthHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// OAuthProviderByName returns the configured provider called name.
+func OAuthProviderByName(name string) (*OAuthProvider, error) {
+	switch name {
+	case "github":
+		return githubProvider()
+	default:
+		return nil, ErrOAuthProviderNotConfigured
+	}
+}
+
+func githubProvider() (*OAuthProvider, error) {
+	p := &OAuthProvider{
+		Name:         "github",
+		ClientID:     os.Getenv("GITHUB_CLIENT_ID"),
+		ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
+		AuthURL:      envOr("GITHUB_AUTH_URL", "https://github.com/login/oauth/authorize"),
+		TokenURL:     envOr("GITHUB_TOKEN_URL", "https://github.com/login/oauth/access_token"),
+		RedirectURL:  envOr("GITHUB_REDIRECT_URL", "http://localhost:8080/auth/oauth/github/callback"),
+		Scopes:       []string{"read:user", "user:email"},
+		FetchProfile: fetchGitHubProfile,
+		apiURL:       strings.TrimRight(envOr("GITHUB_API_URL", "https://api.github.com"), "/"),
+	}
+
+	if p.ClientID == "" || p.ClientSecret == "" {
+		return nil, ErrOAuthProviderNotConfigured
+	}
+	return p, nil
+}
+
+// PKCEChallenge returns the S256 code challenge for verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL builds the URL the browser is sent to for consent.
+func (p *OAuthProvider) AuthCodeURL(state, codeVerifier string) string {
+	values := url.Values{}
+	values.Set("client_id", p.ClientID)
+	values.Set("redirect_uri", p.RedirectURL)
+	values.Set("response_type", "code")
+	values.Set("scope", strings.Join(p.Scopes, " "))
+	values.Set("state", state)
+	values.Set("code_challenge", PKCEChallenge(codeVerifier))
+	values.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.AuthURL + sep + values.Encode()
+}
+
+// Exchange trades an authorization code for an access token.
+func (p *OAuthProvider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("client_id", p.ClientID)
+	form.Set("client_secret", p.ClientSecret)
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("grant_type", "authorization_code")
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("token endpoint returned %d: %w", res.StatusCode, err)
+	}
+	if body.Error != "" {
+		return "", fmt.Errorf("token endpoint: %s: %s", body.Error, body.ErrorDescription)
+	}
+	if res.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned %d without access token", res.StatusCode)
+	}
+
+	return body.AccessToken, nil
+}
+
+func (p *OAuthProvider) getJSON(ctx context.Context, path, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	res, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", path, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func fetchGitHubProfile(ctx context.Context, p *OAuthProvider, accessToken string) (*OAuthProfile, error) {
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := p.getJSON(ctx, "/user", accessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("github user has no id")
+	}
+
+	// The public profile email may be unverified or hidden, so only trust the
+	// primary verified address from /user/emails.
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(ctx, "/user/emails", accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	profile := &OAuthProfile{
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Username:  user.Login,
+		Name:      user.Name,
+		AvatarURL: user.AvatarURL,
+	}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			profile.Email = strings.ToLower(e.Email)
+			profile.EmailVerified = true
+			break
+		}
+	}
+
+	return profile, nil
+}
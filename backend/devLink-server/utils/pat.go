@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// PATPrefix marks personal access tokens so they can be told apart from JWTs
+// in an Authorization header and spotted by secret scanners.
+const PATPrefix = "dlp_"
+
+// TokenScopes are the scopes a personal access token can be granted.
+var TokenScopes = map[string]string{
+	"posts:read":    "Read posts and drafts",
+	"posts:write":   "Create, update and delete posts",
+	"users:read":    "Read user profiles and search users",
+	"follows:write": "Follow and unfollow users",
+	"chat:read":     "Read chat requests and messages",
+	"chat:write":    "Send and answer chat requests, mark messages seen",
+}
+
+var ErrInvalidPAT = errors.New("invalid or expired personal access token")
+
+// GeneratePAT returns a new personal access token.
+func GeneratePAT() (string, error) {
+	token, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	return PATPrefix + token, nil
+}
+
+// IsPAT reports whether token looks like a personal access token.
+func IsPAT(token string) bool {
+	return strings.HasPrefix(token, PATPrefix)
+}
+
+// AuthenticatePAT looks up a live personal access token and its owner.
+func AuthenticatePAT(ctx context.Context, client *mongo.Client, token string) (*models.PersonalAccessToken, *models.User, error) {
+	tokenCol := database.OpenCollection("personal_access_tokens", client)
+
+	var pat models.PersonalAccessToken
+	err := tokenCol.FindOne(ctx, bson.M{
+		"token_hash": HashToken(token),
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&pat)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrInvalidPAT
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var user models.User
+	err = database.OpenCollection("users", client).FindOne(ctx, bson.M{"_id": pat.UserID}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrInvalidPAT
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokenCol.UpdateOne(ctx, bson.M{"_id": pat.ID}, bson.M{"$set": bson.M{"last_used_at": time.Now()}})
+
+	return &pat, &user, nil
+}
@@ -0,0 +1,57 @@
+package utils
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Permissions are attached to routes with middleware.RequirePermission or
+// checked inside handlers with HasPermission.
+const (
+	PermPostsDeleteAny = "posts:delete:any"
+	PermUsersRead      = "users:read:any"
+	PermRolesManage    = "roles:manage"
+	PermEmailsPreview  = "emails:preview"
+	PermEmailsManage   = "emails:manage"
+	PermAuditRead      = "audit:read"
+	PermUsersSuspend   = "users:suspend"
+	PermUsersBan       = "users:ban"
+	PermInvitesManage  = "invites:manage"
+)
+
+var rolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleModerator: {
+		PermPostsDeleteAny,
+		PermUsersRead,
+		PermUsersSuspend,
+	},
+	RoleAdmin: {
+		PermPostsDeleteAny,
+		PermUsersRead,
+		PermUsersSuspend,
+		PermUsersBan,
+		PermInvitesManage,
+		PermRolesManage,
+		PermEmailsPreview,
+		PermEmailsManage,
+		PermAuditRead,
+	},
+}
+
+// IsValidRole reports whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role grants permission.
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
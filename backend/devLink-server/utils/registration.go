@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// Registration modes, selected with REGISTRATION_MODE.
+const (
+	// RegistrationOpen lets anyone sign up.
+	RegistrationOpen = "open"
+	// RegistrationInvite requires a valid invite code.
+	RegistrationInvite = "invite"
+	// RegistrationDomain lets addresses at REGISTRATION_DOMAINS sign up
+	// freely and requires an invite code from everyone else.
+	RegistrationDomain = "domain"
+)
+
+// RegistrationMode returns the configured mode. An unknown value closes
+// registration rather than opening it by accident.
+func RegistrationMode() string {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("REGISTRATION_MODE"))); mode {
+	case "":
+		return RegistrationOpen
+	case RegistrationOpen, RegistrationInvite, RegistrationDomain:
+		return mode
+	default:
+		return RegistrationInvite
+	}
+}
+
+// RegistrationDomains returns the lower-cased domains from the
+// comma-separated REGISTRATION_DOMAINS.
+func RegistrationDomains() []string {
+	var domains []string
+	for _, domain := range strings.Split(os.Getenv("REGISTRATION_DOMAINS"), ",") {
+		if domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@")); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// InviteRequired reports whether signing up with email needs an invite code
+// under the current mode.
+// NormalizeEmail returns the case-folded form emails are compared by.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func InviteRequired(email string) bool {
+	switch RegistrationMode() {
+	case RegistrationOpen:
+		return false
+	case RegistrationDomain:
+		at := strings.LastIndex(email, "@")
+		if at < 0 {
+			return true
+		}
+		domain := strings.ToLower(email[at+1:])
+		for _, allowed := range RegistrationDomains() {
+			if domain == allowed {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Entries in revoked_tokens either name a single access token by its jti, or
+// revoke every access token of a user issued before revoked_before. Both kinds
+// carry expires_at, after which a TTL index removes them because the tokens
+// they cover have expired anyway.
+
+// RevokeToken puts a single access token on the revocation list until it
+// expires.
+func RevokeToken(ctx context.Context, client *mongo.Client, claims *JWTClaims, reason string) error {
+	if claims.ID == "" {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(AccessTokenTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	_, err := database.OpenCollection("revoked_tokens", client).InsertOne(ctx, bson.M{
+		"jti":        claims.ID,
+		"user_id":    claims.UserID,
+		"reason":     reason,
+		"revoked_at": time.Now(),
+		"expires_at": expiresAt,
+	})
+	return err
+}
+
+// RevokeUserTokens revokes every access token issued to the user so far.
+func RevokeUserTokens(ctx context.Context, client *mongo.Client, userID string, reason string) error {
+	now := time.Now()
+
+	// iat only has second precision; truncating keeps tokens issued later in
+	// the same second (e.g. the replacement session) valid.
+	_, err := database.OpenCollection("revoked_tokens", client).InsertOne(ctx, bson.M{
+		"user_id":        userID,
+		"revoked_before": now.Truncate(time.Second),
+		"reason":         reason,
+		"revoked_at":     now,
+		"expires_at":     now.Add(AccessTokenTTL),
+	})
+	return err
+}
+
+// IsTokenRevoked reports whether the access token described by claims is on
+// the revocation list.
+func IsTokenRevoked(ctx context.Context, client *mongo.Client, claims *JWTClaims) (bool, error) {
+	conditions := bson.A{}
+	if claims.ID != "" {
+		conditions = append(conditions, bson.M{"jti": claims.ID})
+	}
+	if claims.IssuedAt != nil {
+		conditions = append(conditions, bson.M{
+			"user_id":        claims.UserID,
+			"revoked_before": bson.M{"$gt": claims.IssuedAt.Time},
+		})
+	}
+	if len(conditions) == 0 {
+		return false, nil
+	}
+
+	count, err := database.OpenCollection("revoked_tokens", client).CountDocuments(ctx, bson.M{"$or": conditions})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
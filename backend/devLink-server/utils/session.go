@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionRevoked     = errors.New("session revoked or expired")
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// maxPreviousHashes bounds how many rotated-out refresh digests a session keeps
+// for reuse detection.
+const maxPreviousHashes = 50
+
+// lastSeenResolution limits how often a session's last_seen_at is written.
+const lastSeenResolution = 5 * time.Minute
+
+// SudoTTL is how long a session may perform sensitive actions after the user
+// signed in or re-authenticated.
+const SudoTTL = 10 * time.Minute
+
+// CreateSession starts a new refresh token family for the user and returns the
+// stored session together with the plain refresh token. When elevated is set,
+// because the user just proved a password or TOTP code, the session starts
+// out elevated for SudoTTL.
+func CreateSession(ctx context.Context, client *mongo.Client, userID bson.ObjectID, userAgent, ip string, elevated bool) (*models.Session, string, error) {
+	refreshToken, err := GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:             bson.NewObjectID(),
+		UserID:         userID,
+		RefreshHash:    HashToken(refreshToken),
+		PreviousHashes: []string{},
+		UserAgent:      userAgent,
+		IP:             ip,
+		CreatedAt:      now,
+		LastSeenAt:     now,
+		LastRefreshAt:  now,
+		ExpiresAt:      now.Add(RefreshTokenTTL),
+	}
+	if elevated {
+		elevatedUntil := now.Add(SudoTTL)
+		session.ElevatedUntil = &elevatedUntil
+	}
+
+	if _, err := database.OpenCollection("sessions", client).InsertOne(ctx, session); err != nil {
+		return nil, "", err
+	}
+
+	return &session, refreshToken, nil
+}
+
+// RotateSession exchanges a refresh token for a new one. Presenting a refresh
+// token that has already been rotated out revokes the whole session; the
+// revoked session is returned along with ErrRefreshTokenReused.
+func RotateSession(ctx context.Context, client *mongo.Client, refreshToken, ip string) (*models.Session, string, error) {
+	sessionCol := database.OpenCollection("sessions", client)
+
+	newToken, err := GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	oldHash := HashToken(refreshToken)
+
+	var session models.Session
+	err = sessionCol.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"refresh_hash": oldHash,
+			"revoked_at":   bson.M{"$exists": false},
+			"expires_at":   bson.M{"$gt": now},
+		},
+		bson.M{
+			"$set": bson.M{
+				"refresh_hash":    HashToken(newToken),
+				"ip":              ip,
+				"last_seen_at":    now,
+				"last_refresh_at": now,
+				"expires_at":      now.Add(RefreshTokenTTL),
+			},
+			"$push": bson.M{
+				"previous_hashes": bson.M{"$each": []string{oldHash}, "$slice": -maxPreviousHashes},
+			},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+
+	if err == nil {
+		return &session, newToken, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, "", err
+	}
+
+	// The token is not the current one of any live session: either it was
+	// already rotated (replay), or the session is gone.
+	err = sessionCol.FindOne(ctx, bson.M{"previous_hashes": oldHash}).Decode(&session)
+	if err == nil {
+		if revokeErr := RevokeSession(ctx, client, session.ID, "refresh_token_reuse"); revokeErr != nil {
+			return nil, "", revokeErr
+		}
+		return &session, "", ErrRefreshTokenReused
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, "", err
+	}
+
+	count, err := sessionCol.CountDocuments(ctx, bson.M{"refresh_hash": oldHash})
+	if err != nil {
+		return nil, "", err
+	}
+	if count > 0 {
+		return nil, "", ErrSessionRevoked
+	}
+
+	return nil, "", ErrSessionNotFound
+}
+
+// FindSessionByRefreshToken returns the live session whose current refresh
+// token is refreshToken.
+func FindSessionByRefreshToken(ctx context.Context, client *mongo.Client, refreshToken string) (*models.Session, error) {
+	var session models.Session
+	err := database.OpenCollection("sessions", client).FindOne(ctx, bson.M{
+		"refresh_hash": HashToken(refreshToken),
+		"revoked_at":   bson.M{"$exists": false},
+	}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ValidateSession checks that the session an access token belongs to is still
+// live, and records that it was just used.
+func ValidateSession(ctx context.Context, client *mongo.Client, sessionID string) (*models.Session, error) {
+	id, err := bson.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	sessionCol := database.OpenCollection("sessions", client)
+
+	var session models.Session
+	err = sessionCol.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return nil, ErrSessionRevoked
+	}
+
+	if now.Sub(session.LastSeenAt) > lastSeenResolution {
+		sessionCol.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_seen_at": now}})
+	}
+
+	return &session, nil
+}
+
+// ElevateSession puts a live session into "sudo mode" for SudoTTL and returns
+// when that ends.
+func ElevateSession(ctx context.Context, client *mongo.Client, sessionID bson.ObjectID) (time.Time, error) {
+	until := time.Now().Add(SudoTTL)
+
+	result, err := database.OpenCollection("sessions", client).UpdateOne(
+		ctx,
+		bson.M{"_id": sessionID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"elevated_until": until}},
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if result.MatchedCount == 0 {
+		return time.Time{}, ErrSessionRevoked
+	}
+	return until, nil
+}
+
+// RevokeSession marks a single session as revoked so its refresh token can no
+// longer be used.
+func RevokeSession(ctx context.Context, client *mongo.Client, sessionID bson.ObjectID, reason string) error {
+	_, err := database.OpenCollection("sessions", client).UpdateOne(
+		ctx,
+		bson.M{"_id": sessionID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason}},
+	)
+	return err
+}
+
+// RevokeUserSessions revokes every live session of a user.
+func RevokeUserSessions(ctx context.Context, client *mongo.Client, userID bson.ObjectID, reason string) error {
+	_, err := database.OpenCollection("sessions", client).UpdateMany(
+		ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason}},
+	)
+	return err
+}
@@ -1,61 +1,170 @@
 package utils
 
 import (
-	"os"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// AccessTokenTTL is how long an access token JWT stays valid. It is kept
+// short so that claims such as the role are refreshed from the database often.
+const AccessTokenTTL = 15 * time.Minute
+
+// RefreshTokenTTL is the idle lifetime of a session: every successful
+// refresh pushes the session expiry this far into the future.
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
 
 type JWTClaims struct{
 	UserID string `json:"user_id"`
 	Email string `json:"email"`
 	Role string `json:"role"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
 
-func GenerateToken(userId,email,role string)(string,error){
+// tokenIssuer is the iss claim of every token DevLink signs.
+const tokenIssuer = "devlink"
+
+// validSigningMethods is the algorithm allow-list applied to every token.
+var validSigningMethods = []string{jwt.SigningMethodEdDSA.Alg()}
 
-	secret:=os.Getenv("JWT_SECRET");
 
+func GenerateToken(userId,email,role,sessionId string)(string,error){
+
+	jti,err:=generateTokenID()
+	if err!=nil{
+		return "",err
+	}
 
 	claims:=JWTClaims{
 		UserID: userId,
 		Email: email,
 		Role: role,
+		SessionID: sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24*time.Hour)),
+			ID: jti,
+			Issuer: tokenIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token:=jwt.NewWithClaims(jwt.SigningMethodHS256,claims)
-
-	return token.SignedString([]byte(secret))
+	return signToken(claims)
 }
 
+// VerifyToken parses and validates an access token. Every entry point that
+// accepts access tokens goes through here.
 func VerifyToken(tokenStr string) (*JWTClaims, error) {
-	secret := os.Getenv("JWT_SECRET")
+	claims := &JWTClaims{}
+	if err := parseToken(tokenStr, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// PurposeClaims are carried by short-lived single-purpose tokens such as the
+// pending-MFA challenge. They never carry a session and are therefore never
+// accepted as access tokens.
+type PurposeClaims struct {
+	UserID  string `json:"user_id"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// GeneratePurposeToken mints a token that is only accepted by
+// VerifyPurposeToken with the same purpose.
+func GeneratePurposeToken(userId, purpose string, ttl time.Duration) (string, error) {
+	jti, err := generateTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := PurposeClaims{
+		UserID:  userId,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    tokenIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return signToken(claims)
+}
 
+func VerifyPurposeToken(tokenStr, purpose string) (*PurposeClaims, error) {
+	claims := &PurposeClaims{}
+	if err := parseToken(tokenStr, claims); err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+func signToken(claims jwt.Claims) (string, error) {
+	key, err := currentSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
+}
+
+func parseToken(tokenStr string, claims jwt.Claims) error {
 	token, err := jwt.ParseWithClaims(
 		tokenStr,
-		&JWTClaims{},
+		claims,
 		func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
+			kid, _ := token.Header["kid"].(string)
+			return verificationKey(kid)
 		},
+		jwt.WithValidMethods(validSigningMethods),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
 	)
-
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if !token.Valid {
+		return jwt.ErrTokenInvalidClaims
 	}
+	return nil
+}
 
-	claims, ok := token.Claims.(*JWTClaims)
-	if !ok || !token.Valid {
-		return nil, jwt.ErrTokenInvalidClaims
+// GenerateOpaqueToken returns a random URL-safe token. Opaque tokens such as
+// refresh tokens are only ever stored as a HashToken digest.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return claims, nil
+// HashToken returns the hex SHA-256 digest of a high-entropy token. Unlike
+// passwords these tokens are random, so a fast hash is enough and lets the
+// digest be used directly as a lookup key.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
+func generateTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+// FrontendURL returns path on the web client, configured by FRONTEND_URL.
+func FrontendURL(path string) string {
+	base := os.Getenv("FRONTEND_URL")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return strings.TrimRight(base, "/") + path
+}
+
+// PublicAPIURL returns path on this API as reachable from browsers and email
+// clients, configured by PUBLIC_API_URL.
+func PublicAPIURL(path string) string {
+	base := os.Getenv("PUBLIC_API_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return strings.TrimRight(base, "/") + path
+}
+
+// AllowedOrigins lists the browser origins allowed to call the API with
+// credentials: the comma-separated ALLOWED_ORIGINS, or the origin of the web
+// client when that is unset.
+func AllowedOrigins() []string {
+	if env := os.Getenv("ALLOWED_ORIGINS"); env != "" {
+		var origins []string
+		for _, origin := range strings.Split(env, ",") {
+			if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+				origins = append(origins, origin)
+			}
+		}
+		return origins
+	}
+
+	u, err := url.Parse(FrontendURL(""))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return []string{"http://localhost:3000"}
+	}
+	return []string{u.Scheme + "://" + u.Host}
+}
+
+// IsAllowedOrigin reports whether origin is one of AllowedOrigins.
+func IsAllowedOrigin(origin string) bool {
+	for _, allowed := range AllowedOrigins() {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return false
+}
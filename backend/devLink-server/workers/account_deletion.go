@@ -0,0 +1,413 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const deletionLease = 10 * time.Minute
+
+// deletionStep removes or anonymises one kind of data belonging to userID and
+// returns how many documents it touched. Steps must be safe to run again after
+// a crash, since a deletion is resumed from its first unfinished step.
+type deletionStep struct {
+	name string
+	run  func(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error)
+}
+
+// deletionSteps lists the steps in the order they run. Steps that need more
+// than the database get it through a closure.
+func deletionSteps(store utils.BlobStore) []deletionStep {
+	return []deletionStep{
+		{"revoke_credentials", revokeCredentials},
+		{"posts", deletePosts},
+		{"chat_requests", deleteChatRequests},
+		{"chat_rooms", leaveChatRooms},
+		{"messages", anonymiseMessages},
+		{"data_exports", func(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+			return deleteDataExports(ctx, client, store, userID)
+		}},
+		{"outbound_emails", deleteOutboundEmails},
+		{"handle_redirects", deleteHandleRedirects},
+		{"profile_changes", deleteProfileChanges},
+		{"follows", deleteFollows},
+		{"avatar", func(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+			return deleteAvatar(ctx, client, store, userID)
+		}},
+		{"invites", revokeInvites},
+		{"audit_events", anonymiseAuditEvents},
+		{"user", deleteUser},
+	}
+}
+
+// RunAccountDeletions processes due account deletions. It never returns.
+func RunAccountDeletions(client *mongo.Client, store utils.BlobStore) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := processNextDeletion(client, store)
+			if err != nil {
+				log.Println("ACCOUNT DELETION FAILED:", err)
+			}
+			if !processed {
+				break
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// processNextDeletion claims one due deletion and runs its remaining steps. It
+// reports whether a deletion was claimed.
+func processNextDeletion(client *mongo.Client, store utils.BlobStore) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), deletionLease)
+	defer cancel()
+
+	col := database.OpenCollection("account_deletions", client)
+	now := time.Now()
+
+	var job models.AccountDeletion
+	err := col.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"active": true,
+			"$or": bson.A{
+				bson.M{"status": models.DeletionPending, "scheduled_for": bson.M{"$lte": now}},
+				bson.M{"status": models.DeletionRunning, "lease_until": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{
+			"$set": bson.M{
+				"status":      models.DeletionRunning,
+				"lease_until": now.Add(deletionLease),
+			},
+			"$min": bson.M{"started_at": now},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "scheduled_for", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	done := map[string]bool{}
+	for _, step := range job.Steps {
+		done[step.Name] = true
+	}
+
+	for _, step := range deletionSteps(store) {
+		if done[step.name] {
+			continue
+		}
+
+		affected, err := step.run(ctx, client, job.UserID)
+		if err != nil {
+			col.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+				"$set": bson.M{"last_error": step.name + ": " + err.Error()},
+			})
+			return true, err
+		}
+
+		_, err = col.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+			"$push": bson.M{"steps": models.DeletionStep{
+				Name:        step.name,
+				Affected:    affected,
+				CompletedAt: time.Now(),
+			}},
+		})
+		if err != nil {
+			return true, err
+		}
+	}
+
+	_, err = col.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+		"$set":   bson.M{"status": models.DeletionCompleted, "completed_at": time.Now()},
+		"$unset": bson.M{"active": "", "lease_until": "", "last_error": ""},
+	})
+	if err == nil {
+		log.Println("Account deletion completed:", job.ID.Hex())
+	}
+	return true, err
+}
+
+func revokeCredentials(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	if err := utils.RevokeUserSessions(ctx, client, userID, "account_deleted"); err != nil {
+		return 0, err
+	}
+	if err := utils.RevokeUserTokens(ctx, client, userID.Hex(), "account_deleted"); err != nil {
+		return 0, err
+	}
+	if _, err := database.OpenCollection("magic_links", client).DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return 0, err
+	}
+
+	result, err := database.OpenCollection("personal_access_tokens", client).UpdateMany(
+		ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func deletePosts(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	result, err := database.OpenCollection("posts", client).DeleteMany(ctx, bson.M{"author_id": userID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func deleteChatRequests(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	result, err := database.OpenCollection("chat_requests", client).DeleteMany(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"sender_id": userID},
+			bson.M{"receiver_id": userID},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// leaveChatRooms removes the user from their rooms. A room that would be left
+// with a single participant is deleted together with its messages.
+func leaveChatRooms(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	roomCol := database.OpenCollection("chat_rooms", client)
+	msgCol := database.OpenCollection("messages", client)
+
+	cursor, err := roomCol.Find(ctx, bson.M{"participants": userID})
+	if err != nil {
+		return 0, err
+	}
+
+	var rooms []models.ChatRoom
+	if err := cursor.All(ctx, &rooms); err != nil {
+		return 0, err
+	}
+
+	var affected int64
+	for _, room := range rooms {
+		if len(room.Participants) <= 2 {
+			// Messages first: if we crash in between, the room still lists
+			// the user and is found again on the next run.
+			if _, err := msgCol.DeleteMany(ctx, bson.M{"room_id": room.ID}); err != nil {
+				return affected, err
+			}
+			if _, err := roomCol.DeleteOne(ctx, bson.M{"_id": room.ID}); err != nil {
+				return affected, err
+			}
+		} else {
+			if _, err := roomCol.UpdateOne(ctx, bson.M{"_id": room.ID}, bson.M{
+				"$pull": bson.M{"participants": userID},
+			}); err != nil {
+				return affected, err
+			}
+		}
+		affected++
+	}
+
+	return affected, nil
+}
+
+// anonymiseMessages blanks whatever messages of the user survive in rooms that
+// still have other participants.
+func anonymiseMessages(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	result, err := database.OpenCollection("messages", client).UpdateMany(
+		ctx,
+		bson.M{"sender_id": userID},
+		bson.M{"$set": bson.M{"sender_id": bson.NilObjectID, "content": ""}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// deleteOutboundEmails drops queued and past mail to the user. Messages being
+// sent right now are left alone and expire with the retention period.
+func deleteOutboundEmails(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	var user models.User
+	err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := database.OpenCollection("outbound_emails", client).DeleteMany(ctx, bson.M{
+		"to":     user.Email,
+		"status": bson.M{"$ne": models.EmailSending},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func deleteHandleRedirects(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	result, err := database.OpenCollection("handle_redirects", client).DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func deleteProfileChanges(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	result, err := database.OpenCollection("profile_changes", client).DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// deleteFollows removes every follow edge of the user in either direction,
+// keeping the other users' counters in step.
+func deleteFollows(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	cursor, err := database.OpenCollection("follows", client).Find(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"follower_id": userID},
+			bson.M{"followee_id": userID},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var affected int64
+	for cursor.Next(ctx) {
+		var follow models.Follow
+		if err := cursor.Decode(&follow); err != nil {
+			return affected, err
+		}
+		removed, err := utils.Unfollow(ctx, client, follow.FollowerID, follow.FolloweeID)
+		if err != nil {
+			return affected, err
+		}
+		if removed {
+			affected++
+		}
+	}
+	return affected, cursor.Err()
+}
+
+// deleteAvatar removes the files of the user's uploaded avatar.
+func deleteAvatar(ctx context.Context, client *mongo.Client, store utils.BlobStore, userID bson.ObjectID) (int64, error) {
+	var user models.User
+	err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := utils.DeleteBlobs(ctx, store, user.AvatarKeys); err != nil {
+		return 0, err
+	}
+	return int64(len(user.AvatarKeys)), nil
+}
+
+// revokeInvites revokes the invites the user created and removes their email
+// from the invite they registered with. Used slots stay used.
+func revokeInvites(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	col := database.OpenCollection("invites", client)
+
+	result, err := col.UpdateMany(
+		ctx,
+		bson.M{"created_by": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := col.UpdateMany(
+		ctx,
+		bson.M{"redemptions.user_id": userID},
+		bson.M{"$pull": bson.M{"redemptions": bson.M{"user_id": userID}}},
+	); err != nil {
+		return result.ModifiedCount, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// anonymiseAuditEvents strips what identifies the user as a person from the
+// audit log: the email, IP address, user agent and session of events about
+// them, and the IP address, user agent and session of events they caused on
+// other accounts. The log itself stays append-only, so the type, time,
+// account and actor of every event are kept.
+func anonymiseAuditEvents(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	col := database.OpenCollection("audit_events", client)
+
+	about, err := col.UpdateMany(ctx, bson.M{"user_id": userID}, bson.M{
+		"$set":   bson.M{"ip": "", "user_agent": ""},
+		"$unset": bson.M{"email": "", "session_id": ""},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	caused, err := col.UpdateMany(ctx, bson.M{"actor_id": userID, "user_id": bson.M{"$ne": userID}}, bson.M{
+		"$set":   bson.M{"ip": "", "user_agent": ""},
+		"$unset": bson.M{"session_id": ""},
+	})
+	if err != nil {
+		return about.ModifiedCount, err
+	}
+	return about.ModifiedCount + caused.ModifiedCount, nil
+}
+
+func deleteDataExports(ctx context.Context, client *mongo.Client, store utils.BlobStore, userID bson.ObjectID) (int64, error) {
+	col := database.OpenCollection("data_exports", client)
+
+	var exports []models.DataExport
+	if err := findAll(ctx, client, "data_exports", bson.M{"user_id": userID}, &exports); err != nil {
+		return 0, err
+	}
+
+	keys := []string{}
+	for _, export := range exports {
+		if export.BlobKey != "" {
+			keys = append(keys, export.BlobKey)
+		}
+	}
+	if err := utils.DeleteBlobs(ctx, store, keys); err != nil {
+		return 0, err
+	}
+
+	result, err := col.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func deleteUser(ctx context.Context, client *mongo.Client, userID bson.ObjectID) (int64, error) {
+	result, err := database.OpenCollection("users", client).DeleteOne(ctx, bson.M{"_id": userID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
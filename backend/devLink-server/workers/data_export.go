@@ -0,0 +1,376 @@
+package workers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	exportLease       = 15 * time.Minute
+	exportMaxAttempts = 3
+	// ExportLinkTTL is how long a finished export can be downloaded.
+	ExportLinkTTL = 7 * 24 * time.Hour
+)
+
+func publicAPIURL() string {
+	return utils.PublicAPIURL("")
+}
+
+// RunDataExports builds requested export archives into store and removes
+// expired ones. It never returns.
+func RunDataExports(client *mongo.Client, mailer utils.Mailer, store utils.BlobStore) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := processNextExport(client, mailer, store)
+			if err != nil {
+				log.Println("DATA EXPORT FAILED:", err)
+			}
+			if !processed {
+				break
+			}
+		}
+		if err := expireExports(client, store); err != nil {
+			log.Println("DATA EXPORT CLEANUP FAILED:", err)
+		}
+		<-ticker.C
+	}
+}
+
+func processNextExport(client *mongo.Client, mailer utils.Mailer, store utils.BlobStore) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportLease)
+	defer cancel()
+
+	col := database.OpenCollection("data_exports", client)
+	now := time.Now()
+
+	var job models.DataExport
+	err := col.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"active": true,
+			"$or": bson.A{
+				bson.M{"status": models.ExportPending},
+				bson.M{"status": models.ExportRunning, "lease_until": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{
+			"$set": bson.M{"status": models.ExportRunning, "lease_until": now.Add(exportLease)},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "requested_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var user models.User
+	if err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"_id": job.UserID}).Decode(&user); err != nil {
+		return true, failExport(ctx, col, job, err, true)
+	}
+
+	key, size, err := buildExportArchive(ctx, client, store, job, user)
+	if err != nil {
+		return true, failExport(ctx, col, job, err, job.Attempts >= exportMaxAttempts)
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		store.Delete(ctx, key)
+		return true, failExport(ctx, col, job, err, false)
+	}
+
+	completedAt := time.Now()
+	expiresAt := completedAt.Add(ExportLinkTTL)
+
+	_, err = col.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+		"$set": bson.M{
+			"status":              models.ExportReady,
+			"completed_at":        completedAt,
+			"expires_at":          expiresAt,
+			"blob_key":            key,
+			"size_bytes":          size,
+			"download_token_hash": utils.HashToken(token),
+		},
+		"$unset": bson.M{"active": "", "lease_until": "", "last_error": ""},
+	})
+	if err != nil {
+		store.Delete(ctx, key)
+		return true, err
+	}
+
+	link := fmt.Sprintf("%s/exports/%s/download?token=%s", publicAPIURL(), job.ID.Hex(), token)
+	if err := utils.SendExportReadyEmail(ctx, mailer, "export:"+job.ID.Hex(), user.Email, user.Locale, link, expiresAt); err != nil {
+		log.Println("EXPORT EMAIL FAILED:", err)
+	}
+
+	return true, nil
+}
+
+func failExport(ctx context.Context, col *mongo.Collection, job models.DataExport, cause error, final bool) error {
+	update := bson.M{"$set": bson.M{"last_error": cause.Error()}}
+	if final {
+		update = bson.M{
+			"$set":   bson.M{"status": models.ExportFailed, "last_error": cause.Error()},
+			"$unset": bson.M{"active": "", "lease_until": ""},
+		}
+	}
+	if _, err := col.UpdateOne(ctx, bson.M{"_id": job.ID}, update); err != nil {
+		return err
+	}
+	return cause
+}
+
+// expireExports deletes the archives of exports whose link has expired.
+func expireExports(client *mongo.Client, store utils.BlobStore) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	col := database.OpenCollection("data_exports", client)
+
+	cursor, err := col.Find(ctx, bson.M{
+		"status":     models.ExportReady,
+		"expires_at": bson.M{"$lt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+
+	var exports []models.DataExport
+	if err := cursor.All(ctx, &exports); err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.BlobKey != "" {
+			if err := store.Delete(ctx, export.BlobKey); err != nil {
+				log.Println("EXPORT CLEANUP: failed to remove", export.BlobKey, err)
+				continue
+			}
+		}
+		col.UpdateOne(ctx, bson.M{"_id": export.ID}, bson.M{
+			"$set":   bson.M{"status": models.ExportExpired},
+			"$unset": bson.M{"blob_key": "", "download_token_hash": ""},
+		})
+	}
+
+	return nil
+}
+
+// buildExportArchive builds the zip for job, stores it under
+// exports/<user>/<export>.zip and returns the key and size.
+func buildExportArchive(ctx context.Context, client *mongo.Client, store utils.BlobStore, job models.DataExport, user models.User) (string, int64, error) {
+	var buf bytes.Buffer
+	if err := writeExport(ctx, client, zip.NewWriter(&buf), user); err != nil {
+		return "", 0, err
+	}
+
+	key := fmt.Sprintf("exports/%s/%s.zip", user.Id.Hex(), job.ID.Hex())
+	if err := store.Put(ctx, key, buf.Bytes(), "application/zip"); err != nil {
+		return "", 0, err
+	}
+	return key, int64(buf.Len()), nil
+}
+
+func writeExport(ctx context.Context, client *mongo.Client, zw *zip.Writer, user models.User) error {
+	// Listed explicitly so that secrets on models.User never end up here.
+	profile := map[string]interface{}{
+		"id":            user.Id.Hex(),
+		"name":          user.UserName,
+		"handle":        user.Handle,
+		"email":         user.Email,
+		"bio":           user.Bio,
+		"role":          user.Role,
+		"profile_image": user.ProfileImage,
+		"is_verified":   user.IsVerified,
+		"created_at":    user.CreatedAt,
+		"updated_at":    user.UpdatedAt,
+
+		"followers_count": user.FollowersCount,
+		"following_count": user.FollowingCount,
+	}
+
+	var posts []models.Post
+	if err := findAll(ctx, client, "posts", bson.M{"author_id": user.Id}, &posts); err != nil {
+		return err
+	}
+
+	var requests []models.ChatRequest
+	if err := findAll(ctx, client, "chat_requests", bson.M{
+		"$or": bson.A{bson.M{"sender_id": user.Id}, bson.M{"receiver_id": user.Id}},
+	}, &requests); err != nil {
+		return err
+	}
+
+	var rooms []models.ChatRoom
+	if err := findAll(ctx, client, "chat_rooms", bson.M{"participants": user.Id}, &rooms); err != nil {
+		return err
+	}
+
+	roomIDs := make([]bson.ObjectID, 0, len(rooms))
+	for _, room := range rooms {
+		roomIDs = append(roomIDs, room.ID)
+	}
+
+	var messages []models.Message
+	if err := findAll(ctx, client, "messages", bson.M{"room_id": bson.M{"$in": roomIDs}}, &messages); err != nil {
+		return err
+	}
+
+	var profileChanges []models.ProfileChange
+	if err := findAll(ctx, client, "profile_changes", bson.M{"user_id": user.Id}, &profileChanges); err != nil {
+		return err
+	}
+
+	var following []models.Follow
+	if err := findAll(ctx, client, "follows", bson.M{"follower_id": user.Id}, &following); err != nil {
+		return err
+	}
+
+	var followers []models.Follow
+	if err := findAll(ctx, client, "follows", bson.M{"followee_id": user.Id}, &followers); err != nil {
+		return err
+	}
+
+	var events []models.AuditEvent
+	if err := findAll(ctx, client, "audit_events", bson.M{"user_id": user.Id}, &events); err != nil {
+		return err
+	}
+
+	var invites []models.Invite
+	if err := findAll(ctx, client, "invites", bson.M{"created_by": user.Id}, &invites); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"profile_history.json", profileChanges},
+		{"posts.json", posts},
+		{"chat_requests.json", requests},
+		{"chat_rooms.json", rooms},
+		{"messages.json", messages},
+		{"follows.json", map[string]interface{}{"following": following, "followers": followers}},
+		{"security_events.json", events},
+		{"invites.json", invites},
+	}
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+	}
+
+	for _, post := range posts {
+		w, err := zw.Create("posts/" + exportFileName(post.Slug, post.ID) + ".md")
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte(postMarkdown(post))); err != nil {
+			return err
+		}
+	}
+
+	byRoom := map[bson.ObjectID][]models.Message{}
+	for _, msg := range messages {
+		byRoom[msg.RoomID] = append(byRoom[msg.RoomID], msg)
+	}
+	for _, room := range rooms {
+		w, err := zw.Create("messages/" + room.ID.Hex() + ".md")
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte(roomMarkdown(room, byRoom[room.ID], user.Id))); err != nil {
+			return err
+		}
+	}
+
+	w, err := zw.Create("README.md")
+	if err != nil {
+		return err
+	}
+	readme := fmt.Sprintf("# DevLink data export\n\nExported for %s on %s.\n\n"+
+		"- `profile.json` — your profile\n"+
+		"- `profile_history.json` — earlier versions of your name, bio and avatar\n"+
+		"- `posts.json`, `posts/*.md` — your posts, published and drafts\n"+
+		"- `chat_requests.json` — chat requests you sent or received\n"+
+		"- `chat_rooms.json`, `messages.json`, `messages/*.md` — your conversations\n"+
+		"- `follows.json` — who you follow and who follows you\n"+
+		"- `security_events.json` — sign-ins and other security events on your account\n"+
+		"- `invites.json` — invite codes you created and who used them\n",
+		user.Email, time.Now().UTC().Format(time.RFC3339))
+	if _, err := w.Write([]byte(readme)); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func findAll(ctx context.Context, client *mongo.Client, collection string, filter bson.M, out interface{}) error {
+	cursor, err := database.OpenCollection(collection, client).Find(
+		ctx,
+		filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, out)
+}
+
+func exportFileName(slug string, id bson.ObjectID) string {
+	if slug == "" {
+		return id.Hex()
+	}
+	return strings.NewReplacer("/", "-", "\\", "-", "..", "-").Replace(slug)
+}
+
+func postMarkdown(post models.Post) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\ntitle: %q\nslug: %q\npublished: %t\ntags: [%s]\ncreated_at: %s\nupdated_at: %s\nviews: %d\n---\n\n",
+		post.Title, post.Slug, post.Published, strings.Join(post.Tags, ", "),
+		post.CreatedAt.UTC().Format(time.RFC3339), post.UpdatedAt.UTC().Format(time.RFC3339), post.ViewCount)
+	fmt.Fprintf(&b, "# %s\n\n%s\n", post.Title, post.Content)
+	return b.String()
+}
+
+func roomMarkdown(room models.ChatRoom, messages []models.Message, self bson.ObjectID) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Conversation %s\n\nStarted %s\n\n", room.ID.Hex(), room.CreatedAt.UTC().Format(time.RFC3339))
+	for _, msg := range messages {
+		sender := msg.SenderID.Hex()
+		if msg.SenderID == self {
+			sender = "you"
+		}
+		fmt.Fprintf(&b, "**%s** (%s): %s\n\n", sender, msg.CreatedAt.UTC().Format(time.RFC3339), msg.Content)
+	}
+	return b.String()
+}
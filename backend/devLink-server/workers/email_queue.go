@@ -0,0 +1,157 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"net/textproto"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	emailLease        = 2 * time.Minute
+	emailMaxAttempts  = 8
+	emailBaseBackoff  = 30 * time.Second
+	emailMaxBackoff   = 2 * time.Hour
+	emailRetention    = 30 * 24 * time.Hour
+	emailPollInterval = 5 * time.Second
+)
+
+// RunEmailQueue delivers queued outbound emails through transport. It never
+// returns.
+func RunEmailQueue(client *mongo.Client, transport utils.Mailer) {
+	ticker := time.NewTicker(emailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := processNextEmail(client, transport)
+			if err != nil {
+				log.Println("EMAIL DELIVERY FAILED:", err)
+			}
+			if !processed {
+				break
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// processNextEmail claims one due message and tries to deliver it. It reports
+// whether a message was claimed.
+func processNextEmail(client *mongo.Client, transport utils.Mailer) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), emailLease)
+	defer cancel()
+
+	col := database.OpenCollection("outbound_emails", client)
+	now := time.Now()
+
+	var email models.OutboundEmail
+	err := col.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"$or": bson.A{
+				bson.M{"status": models.EmailQueued, "next_attempt_at": bson.M{"$lte": now}},
+				bson.M{"status": models.EmailSending, "lease_until": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{
+			"$set": bson.M{
+				"status":      models.EmailSending,
+				"lease_until": now.Add(emailLease),
+			},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	sendErr := transport.Send(ctx, utils.Message{
+		To:             email.To,
+		Subject:        email.Subject,
+		Text:           email.Text,
+		HTML:           email.HTML,
+		Template:       email.Template,
+		IdempotencyKey: email.IdempotencyKey,
+	})
+
+	now = time.Now()
+	expiresAt := now.Add(emailRetention)
+
+	if sendErr == nil {
+		// The body may contain one-time codes, so it is not kept once sent.
+		_, err = col.UpdateOne(ctx, bson.M{"_id": email.ID}, bson.M{
+			"$set":   bson.M{"status": models.EmailSent, "sent_at": now, "expires_at": expiresAt},
+			"$unset": bson.M{"lease_until": "", "last_error": "", "text": "", "html": ""},
+		})
+		return true, err
+	}
+
+	if email.Attempts >= emailMaxAttempts || isPermanentMailError(sendErr) {
+		// As with sent mail, the body is dropped: an undeliverable code
+		// must not sit in the outbox until the record expires.
+		_, err = col.UpdateOne(ctx, bson.M{"_id": email.ID}, bson.M{
+			"$set": bson.M{
+				"status":     models.EmailDead,
+				"dead_at":    now,
+				"expires_at": expiresAt,
+				"last_error": sendErr.Error(),
+			},
+			"$unset": bson.M{"lease_until": "", "text": "", "html": ""},
+		})
+		if err == nil {
+			log.Println("Email dead-lettered:", email.ID.Hex(), sendErr)
+		}
+		return true, err
+	}
+
+	_, err = col.UpdateOne(ctx, bson.M{"_id": email.ID}, bson.M{
+		"$set": bson.M{
+			"status":          models.EmailQueued,
+			"next_attempt_at": now.Add(emailBackoff(email.Attempts)),
+			"last_error":      sendErr.Error(),
+		},
+		"$unset": bson.M{"lease_until": ""},
+	})
+	if err != nil {
+		return true, err
+	}
+	return true, sendErr
+}
+
+// emailBackoff returns the delay before retry number attempts+1: the base
+// delay doubled per failed attempt, capped, with up to 20% jitter so that a
+// burst of failures does not retry in lockstep.
+func emailBackoff(attempts int) time.Duration {
+	backoff := float64(emailBaseBackoff) * math.Pow(2, float64(attempts-1))
+	if backoff > float64(emailMaxBackoff) {
+		backoff = float64(emailMaxBackoff)
+	}
+	jitter := backoff * 0.2 * rand.Float64()
+	return time.Duration(backoff + jitter)
+}
+
+// isPermanentMailError reports whether retrying cannot help because the SMTP
+// server rejected the recipient or message itself (550-553). Other 5xx
+// replies, such as failed authentication, are usually configuration problems
+// and are retried.
+func isPermanentMailError(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 550 && protoErr.Code <= 553
+}
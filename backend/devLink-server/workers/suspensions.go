@@ -0,0 +1,67 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// RunSuspensionExpiry lifts suspensions whose end date has passed, so that the
+// user's posts show up in feeds again. It never returns.
+func RunSuspensionExpiry(client *mongo.Client) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if err := expireSuspensions(client); err != nil {
+			log.Println("SUSPENSION EXPIRY FAILED:", err)
+		}
+		<-ticker.C
+	}
+}
+
+func expireSuspensions(client *mongo.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	col := database.OpenCollection("users", client)
+	now := time.Now()
+
+	cursor, err := col.Find(
+		ctx,
+		bson.M{"suspension.until": bson.M{"$lte": now}},
+		options.Find().SetProjection(bson.M{"suspension": 1, "ban": 1}),
+	)
+	if err != nil {
+		return err
+	}
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		// As in UnsuspendUser, posts are unflagged before the suspension is
+		// removed so that a failure here is retried on the next tick.
+		if err := utils.SetAuthorRestricted(ctx, client, user.Id, user.Ban != nil); err != nil {
+			return err
+		}
+		if _, err := col.UpdateOne(
+			ctx,
+			bson.M{"_id": user.Id, "suspension.until": bson.M{"$lte": now}},
+			bson.M{"$unset": bson.M{"suspension": ""}, "$set": bson.M{"updated_at": now}},
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
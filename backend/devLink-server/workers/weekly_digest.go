@@ -0,0 +1,135 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/ayushmehta03/devLink-backend/database"
+	"github.com/ayushmehta03/devLink-backend/models"
+	"github.com/ayushmehta03/devLink-backend/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	digestInterval = 7 * 24 * time.Hour
+	digestPosts    = 5
+)
+
+// RunWeeklyDigests emails every verified user a summary of the week's most
+// read posts and their pending chat requests, at most once per digestInterval.
+// It never returns.
+func RunWeeklyDigests(client *mongo.Client, mailer utils.Mailer) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := processNextDigest(client, mailer)
+			if err != nil {
+				log.Println("WEEKLY DIGEST FAILED:", err)
+			}
+			if !processed {
+				break
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// processNextDigest claims one user whose digest is due by stamping
+// digest_sent_at, then builds and sends it. It reports whether a user was
+// claimed. A failed send is not retried until the next interval.
+func processNextDigest(client *mongo.Client, mailer utils.Mailer) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	due := now.Add(-digestInterval)
+
+	var user models.User
+	err := database.OpenCollection("users", client).FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"is_verified": true,
+			"created_at":  bson.M{"$lte": due},
+			"$or": bson.A{
+				bson.M{"digest_sent_at": bson.M{"$exists": false}},
+				bson.M{"digest_sent_at": bson.M{"$lte": due}},
+			},
+		},
+		bson.M{"$set": bson.M{"digest_sent_at": now}},
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	data, err := buildDigest(ctx, client, user, due)
+	if err != nil {
+		return true, err
+	}
+	if len(data.Posts) == 0 && data.PendingRequests == 0 {
+		return true, nil
+	}
+
+	return true, utils.SendWeeklyDigestEmail(ctx, mailer, "digest:"+user.Id.Hex()+":"+now.Format("2006-01-02"), user.Email, user.Locale, data)
+}
+
+func buildDigest(ctx context.Context, client *mongo.Client, user models.User, since time.Time) (utils.WeeklyDigestEmailData, error) {
+	data := utils.WeeklyDigestEmailData{
+		Name: user.UserName,
+		URL:  utils.FrontendURL("/dashboard"),
+	}
+
+	cursor, err := database.OpenCollection("posts", client).Find(
+		ctx,
+		bson.M{
+			"published":  true,
+			"author_id":  bson.M{"$ne": user.Id},
+			"created_at": bson.M{"$gte": since},
+		},
+		options.Find().
+			SetSort(bson.D{{Key: "view_count", Value: -1}}).
+			SetLimit(digestPosts),
+	)
+	if err != nil {
+		return data, err
+	}
+
+	var posts []models.Post
+	if err := cursor.All(ctx, &posts); err != nil {
+		return data, err
+	}
+
+	userCol := database.OpenCollection("users", client)
+	for _, post := range posts {
+		var author models.User
+		if err := userCol.FindOne(ctx, bson.M{"_id": post.AuthorID}).Decode(&author); err != nil {
+			continue
+		}
+
+		data.Posts = append(data.Posts, utils.DigestPost{
+			Title:  post.Title,
+			Author: author.UserName,
+			Views:  post.ViewCount,
+			URL:    utils.FrontendURL("/post/" + url.PathEscape(post.Slug)),
+		})
+	}
+
+	data.PendingRequests, err = database.OpenCollection("chat_requests", client).CountDocuments(ctx, bson.M{
+		"receiver_id": user.Id,
+		"status":      "pending",
+	})
+	if err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
@@ -11,9 +11,6 @@ const nextConfig = {
       {
         protocol:"https",
         hostname:"picsum.photos",
-      },{
-         protocol:"https",
-        hostname:"api.dicebear.com",
       },
       {
         protocol:"https",
@@ -3,7 +3,7 @@
 import { useEffect, useState } from "react";
 import Image from "next/image";
 import { useRouter } from "next/navigation";
-import { apiFetch } from "@/lib/api";
+import { apiFetch, initialsAvatar } from "@/lib/api";
 import {
   FiBell,
   FiHome,
@@ -101,10 +101,7 @@ export default function DashboardPage() {
 
                 <div className="flex items-center gap-2 text-xs text-[#92adc9] mt-3">
                   <img
-                    src={
-                      user.profile_image ||
-                      "https://api.dicebear.com/7.x/initials/svg?seed=ayush_dev"
-                    }
+                    src={user.profile_image || initialsAvatar(user.username)}
                     alt="user"
                     className="w-[18px] h-[18px] rounded-full"
                   />
@@ -198,7 +195,7 @@ export default function DashboardPage() {
                       <img
                         src={
                           post.author.profile_image ||
-                          `https://api.dicebear.com/7.x/initials/svg?seed=${post.author.username}`
+                          initialsAvatar(post.author.username)
                         }
                         alt={post.author.username}
                         className="w-8 h-8 rounded-full border border-slate-700"
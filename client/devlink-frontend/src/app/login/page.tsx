@@ -1,6 +1,6 @@
 "use client";
 
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import { useRouter } from "next/navigation";
 import toast from "react-hot-toast";
 import { apiFetch } from "@/lib/api";
@@ -14,16 +14,36 @@ export default function Page() {
   const [password, setPassword] = useState("");
   const [loading, setLoading] = useState(false);
 
+  // Set once the first factor was accepted and a two-factor code is still
+  // needed. A password login hands over the challenge token; after a
+  // provider or a sign-in link (?mfa=1) it waits in an httpOnly cookie.
+  const [mfaPending, setMfaPending] = useState(false);
+  const [mfaToken, setMfaToken] = useState("");
+  const [code, setCode] = useState("");
+  const [useRecovery, setUseRecovery] = useState(false);
+
+  useEffect(() => {
+    if (new URLSearchParams(window.location.search).get("mfa") === "1") {
+      setMfaPending(true);
+    }
+  }, []);
+
   const handleLogin = async (e: React.FormEvent) => {
     e.preventDefault();
     setLoading(true);
 
     try {
-      await apiFetch("/auth/login", {
+      const res = await apiFetch("/auth/login", {
         method: "POST",
         body: JSON.stringify({ email, password }),
       });
 
+      if (res.code === "MFA_REQUIRED") {
+        setMfaToken(res.mfa_token);
+        setMfaPending(true);
+        return;
+      }
+
       toast.success("Welcome back!");
       router.push("/dashboard");
     } catch (err: any) {
@@ -63,6 +83,40 @@ export default function Page() {
     }
   };
 
+  const handleVerify = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setLoading(true);
+
+    try {
+      await apiFetch("/auth/2fa/verify", {
+        method: "POST",
+        body: JSON.stringify({
+          ...(mfaToken ? { mfa_token: mfaToken } : {}),
+          ...(useRecovery
+            ? { recovery_code: code.trim() }
+            : { code: code.trim() }),
+        }),
+      });
+
+      toast.success("Welcome back!");
+      router.push("/dashboard");
+    } catch (err: any) {
+      const errorMessage: string = err?.error || "Verification failed";
+
+      // The challenge expired or was tampered with: start over.
+      if (errorMessage.toLowerCase().includes("challenge")) {
+        setMfaPending(false);
+        setMfaToken("");
+        router.replace("/login");
+      }
+
+      setCode("");
+      toast.error(errorMessage);
+    } finally {
+      setLoading(false);
+    }
+  };
+
   return (
     <main className="min-h-screen px-4 bg-[var(--color-background-dark)] flex flex-col items-center">
       <div className="w-full max-w-md py-16">
@@ -93,51 +147,96 @@ export default function Page() {
             </p>
           </div>
 
-          <form onSubmit={handleLogin} className="space-y-4">
-            <div>
-              <label className="block text-sm font-medium text-slate-700 dark:text-slate-300 mb-1">
-                Email address
-              </label>
-              <input
-                type="email"
-                required
-                value={email}
-                onChange={(e) => setEmail(e.target.value)}
-                placeholder="you@devlink.com"
-                className="w-full h-12 rounded-lg border border-slate-300 dark:border-slate-700 bg-white dark:bg-[#192633] px-4 text-slate-900 dark:text-white placeholder:text-slate-400 focus:outline-none focus:ring-2 focus:ring-primary/50"
-              />
-            </div>
+          {mfaPending ? (
+            <form onSubmit={handleVerify} className="space-y-4">
+              <div>
+                <label className="block text-sm font-medium text-slate-700 dark:text-slate-300 mb-1">
+                  {useRecovery ? "Recovery code" : "Authentication code"}
+                </label>
+                <input
+                  type="text"
+                  required
+                  autoFocus
+                  autoComplete="one-time-code"
+                  inputMode={useRecovery ? "text" : "numeric"}
+                  value={code}
+                  onChange={(e) => setCode(e.target.value)}
+                  placeholder={useRecovery ? "xxxxx-xxxxx" : "123456"}
+                  className="w-full h-12 rounded-lg border border-slate-300 dark:border-slate-700 bg-white dark:bg-[#192633] px-4 text-slate-900 dark:text-white placeholder:text-slate-400 focus:outline-none focus:ring-2 focus:ring-primary/50"
+                />
+                <p className="text-slate-500 dark:text-slate-400 mt-1 text-xs">
+                  {useRecovery
+                    ? "Enter one of the recovery codes you saved."
+                    : "Enter the code from your authenticator app."}
+                </p>
+              </div>
 
-            <div>
-              <div className="flex justify-between items-center mb-1">
-                <label className="text-sm font-medium text-slate-700 dark:text-slate-300">
-                  Password
+              <button
+                type="submit"
+                disabled={loading}
+                className="w-full h-12 bg-blue-600 text-white font-semibold rounded-lg shadow-md shadow-primary/20 hover:opacity-95 active:scale-[0.98] transition disabled:opacity-60"
+              >
+                {loading ? "Verifying…" : "Verify"}
+              </button>
+
+              <button
+                type="button"
+                onClick={() => {
+                  setUseRecovery(!useRecovery);
+                  setCode("");
+                }}
+                className="w-full text-primary text-sm font-semibold hover:underline"
+              >
+                {useRecovery ? "Use an authentication code" : "Use a recovery code"}
+              </button>
+            </form>
+          ) : (
+            <form onSubmit={handleLogin} className="space-y-4">
+              <div>
+                <label className="block text-sm font-medium text-slate-700 dark:text-slate-300 mb-1">
+                  Email address
                 </label>
-                <button
-                  type="button"
-                  className="text-primary text-sm font-semibold hover:underline"
-                >
-                  Forgot?
-                </button>
+                <input
+                  type="email"
+                  required
+                  value={email}
+                  onChange={(e) => setEmail(e.target.value)}
+                  placeholder="you@devlink.com"
+                  className="w-full h-12 rounded-lg border border-slate-300 dark:border-slate-700 bg-white dark:bg-[#192633] px-4 text-slate-900 dark:text-white placeholder:text-slate-400 focus:outline-none focus:ring-2 focus:ring-primary/50"
+                />
               </div>
-              <input
-                type="password"
-                required
-                value={password}
-                onChange={(e) => setPassword(e.target.value)}
-                placeholder="••••••••"
-                className="w-full h-12 rounded-lg border border-slate-300 dark:border-slate-700 bg-white dark:bg-[#192633] px-4 text-slate-900 dark:text-white placeholder:text-slate-400 focus:outline-none focus:ring-2 focus:ring-primary/50"
-              />
-            </div>
 
-            <button
-              type="submit"
-              disabled={loading}
-              className="w-full h-12 bg-blue-600 text-white font-semibold rounded-lg shadow-md shadow-primary/20 hover:opacity-95 active:scale-[0.98] transition disabled:opacity-60"
-            >
-              {loading ? "Logging in…" : "Log in"}
-            </button>
-          </form>
+              <div>
+                <div className="flex justify-between items-center mb-1">
+                  <label className="text-sm font-medium text-slate-700 dark:text-slate-300">
+                    Password
+                  </label>
+                  <button
+                    type="button"
+                    className="text-primary text-sm font-semibold hover:underline"
+                  >
+                    Forgot?
+                  </button>
+                </div>
+                <input
+                  type="password"
+                  required
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                  placeholder="••••••••"
+                  className="w-full h-12 rounded-lg border border-slate-300 dark:border-slate-700 bg-white dark:bg-[#192633] px-4 text-slate-900 dark:text-white placeholder:text-slate-400 focus:outline-none focus:ring-2 focus:ring-primary/50"
+                />
+              </div>
+
+              <button
+                type="submit"
+                disabled={loading}
+                className="w-full h-12 bg-blue-600 text-white font-semibold rounded-lg shadow-md shadow-primary/20 hover:opacity-95 active:scale-[0.98] transition disabled:opacity-60"
+              >
+                {loading ? "Logging in…" : "Log in"}
+              </button>
+            </form>
+          )}
 
           {/* Footer */}
           <p className="text-center text-sm text-slate-500 dark:text-slate-400 mt-6">
@@ -0,0 +1,47 @@
+"use client";
+
+import { useEffect, useRef, useState } from "react";
+import { useRouter } from "next/navigation";
+import toast from "react-hot-toast";
+import { apiFetch } from "@/lib/api";
+
+export default function MagicLinkPage() {
+  const router = useRouter();
+
+  const consumedRef = useRef(false);
+  const [token, setToken] = useState<string | null>(null);
+  const [error, setError] = useState("");
+
+  useEffect(() => {
+    setToken(new URLSearchParams(window.location.search).get("token") || "");
+  }, []);
+
+  useEffect(() => {
+    if (!token || consumedRef.current) return;
+    consumedRef.current = true;
+
+    apiFetch("/auth/magic-link/consume", {
+      method: "POST",
+      body: JSON.stringify({ token }),
+    })
+      .then((res) => {
+        if (res.code === "MFA_REQUIRED") {
+          router.replace("/login?mfa=1");
+          return;
+        }
+        toast.success("Logged in");
+        router.replace("/dashboard");
+      })
+      .catch((err: any) => setError(err.error || "Sign-in link failed"));
+  }, [token, router]);
+
+  return (
+    <main className="min-h-screen bg-[var(--color-background-dark)] flex items-center justify-center px-4">
+      <p className="text-slate-400 text-sm">
+        {token === ""
+          ? "Invalid sign-in link"
+          : error || "Signing you in..."}
+      </p>
+    </main>
+  );
+}
@@ -1,6 +1,6 @@
 "use client";
 
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import { useRouter } from "next/navigation";
 import toast from "react-hot-toast";
 import { apiFetch } from "@/lib/api";
@@ -13,8 +13,19 @@ export default function Page() {
   const [email, setEmail] = useState("");
   const [password, setPassword] = useState("");
   const [bio, setBio] = useState("");
+  const [inviteCode, setInviteCode] = useState("");
+  const [registrationMode, setRegistrationMode] = useState("open");
   const [loading, setLoading] = useState(false);
 
+  useEffect(() => {
+    const invite = new URLSearchParams(window.location.search).get("invite");
+    if (invite) setInviteCode(invite);
+
+    apiFetch("/auth/registration")
+      .then((data) => setRegistrationMode(data.mode))
+      .catch(() => {});
+  }, []);
+
   const handleRegister = async (e: React.FormEvent) => {
     e.preventDefault();
     setLoading(true);
@@ -27,6 +38,7 @@ export default function Page() {
           email,
           password,
           bio: bio || undefined,
+          invite_code: inviteCode || undefined,
         }),
       });
 
@@ -124,6 +136,27 @@ export default function Page() {
               />
             </div>
 
+            {registrationMode !== "open" && (
+              <div>
+                <label className="block text-sm font-medium text-slate-700 dark:text-slate-300 mb-1">
+                  Invite code{" "}
+                  {registrationMode === "domain" && (
+                    <span className="text-slate-400">
+                      (not needed for allowed email domains)
+                    </span>
+                  )}
+                </label>
+                <input
+                  type="text"
+                  required={registrationMode === "invite"}
+                  value={inviteCode}
+                  onChange={(e) => setInviteCode(e.target.value)}
+                  placeholder="XXXX-XXXX-XXXX"
+                  className="w-full h-12 rounded-lg border border-slate-300 dark:border-slate-700 bg-white dark:bg-[#192633] px-4 font-mono uppercase text-slate-900 dark:text-white placeholder:text-slate-400 focus:outline-none focus:ring-2 focus:ring-primary/50"
+                />
+              </div>
+            )}
+
             <button
               type="submit"
               disabled={loading}
@@ -1,18 +1,93 @@
 const API_URL = process.env.NEXT_PUBLIC_API_URL;
 
+const NO_REFRESH = [
+  "/auth/login",
+  "/auth/refresh",
+  "/auth/logout",
+  "/auth/magic-link/consume",
+  "/auth/2fa/verify",
+];
+
+const SAFE_METHODS = ["GET", "HEAD", "OPTIONS"];
+
+// Fallback for users without a profile image; the API draws their initials.
+export const initialsAvatar = (seed: string) =>
+  `${API_URL}/avatars/initials.svg?seed=${encodeURIComponent(seed)}`;
+
+let refreshing: Promise<boolean> | null = null;
+let csrfToken: Promise<string> | null = null;
+
+// State-changing requests must echo the csrf_token cookie in X-CSRF-Token.
+// The API may live on another origin, so the token is fetched rather than
+// read from document.cookie.
+const getCsrfToken = (force = false) => {
+  if (!csrfToken || force) {
+    csrfToken = fetch(`${API_URL}/auth/csrf`, { credentials: "include" })
+      .then((res) => res.json())
+      .then((data) => data.csrf_token as string)
+      .catch((err) => {
+        csrfToken = null;
+        throw err;
+      });
+  }
+  return csrfToken;
+};
+
+// Access tokens are short-lived; on a 401 we rotate the refresh token once
+// and replay the original request.
+const refreshSession = () => {
+  if (!refreshing) {
+    refreshing = getCsrfToken()
+      .then((token) =>
+        fetch(`${API_URL}/auth/refresh`, {
+          method: "POST",
+          credentials: "include",
+          headers: { "X-CSRF-Token": token },
+        })
+      )
+      .then((res) => res.ok)
+      .catch(() => false)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+};
+
 export const apiFetch = async (
   url: string,
-  options: RequestInit = {}
-) => {
+  options: RequestInit = {},
+  retry = true
+): Promise<any> => {
+  const method = (options.method || "GET").toUpperCase();
+  const csrf = SAFE_METHODS.includes(method)
+    ? {}
+    : { "X-CSRF-Token": await getCsrfToken() };
+
   const res = await fetch(`${API_URL}${url}`, {
     ...options,
     credentials: "include",
     headers: {
       "Content-Type": "application/json",
+      ...csrf,
       ...(options.headers || {}),
     },
   });
 
+  if (res.status === 403 && retry) {
+    const err = await res.clone().json().catch(() => ({}));
+    if (err.code === "CSRF_TOKEN_INVALID") {
+      await getCsrfToken(true);
+      return apiFetch(url, options, false);
+    }
+  }
+
+  if (res.status === 401 && retry && !NO_REFRESH.includes(url)) {
+    if (await refreshSession()) {
+      return apiFetch(url, options, false);
+    }
+  }
+
   if (!res.ok) {
     const err = await res.json().catch(() => ({}));
     throw err;